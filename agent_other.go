@@ -0,0 +1,21 @@
+//go:build windows || plan9 || js || wasip1
+// +build windows plan9 js wasip1
+
+package exec
+
+// ForwardSocket makes the Unix domain socket at host available to the
+// child at guest. It is not supported on Windows, Plan 9, js or wasip1.
+func ForwardSocket(host, guest string) func(*Cmd) error {
+	return Describe("ForwardSocket", []Param{{"host", host}, {"guest", guest}}, func(*Cmd) error {
+		return notSupported("ForwardSocket")
+	})
+}
+
+// ForwardSSHAgent makes the SSH agent of the parent available to the
+// child, including one run with Chroot. It is not supported on Windows,
+// Plan 9, js or wasip1.
+func ForwardSSHAgent() func(*Cmd) error {
+	return Describe("ForwardSSHAgent", nil, func(*Cmd) error {
+		return notSupported("ForwardSSHAgent")
+	})
+}
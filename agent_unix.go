@@ -0,0 +1,152 @@
+//go:build !windows && !plan9 && !js && !wasip1
+// +build !windows,!plan9,!js,!wasip1
+
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// agentSeq distinguishes the sockets created by ForwardSSHAgent.
+var agentSeq uint32
+
+// ForwardSocket makes the Unix domain socket at host, such as that of a
+// GPG agent, available to the child at guest. guest is interpreted
+// relative to the root set by Chroot, if any, so a sandboxed child can
+// reach a socket outside its root. Connections to guest are proxied to
+// host for as long as the command runs; the directory containing guest
+// is created if need be, and guest is removed once the command has
+// exited.
+func ForwardSocket(host, guest string) func(*Cmd) error {
+	return Describe("ForwardSocket", []Param{{"host", host}, {"guest", guest}}, func(c *Cmd) error {
+		c.starting = append(c.starting, func(c *Cmd) error {
+			return forwardSocket(c, host, guest)
+		})
+		return nil
+	})
+}
+
+// ForwardSSHAgent makes the SSH agent named by SSH_AUTH_SOCK in the
+// child's environment available to it by forwarding the agent's socket
+// into a new directory in /tmp and pointing SSH_AUTH_SOCK at it. The
+// directory is created within the root set by Chroot, if any, so the
+// agent remains reachable from a child which cannot see the original
+// socket; without Chroot it is created in the parent's /tmp.
+func ForwardSSHAgent() func(*Cmd) error {
+	return Describe("ForwardSSHAgent", nil, func(c *Cmd) error {
+		c.starting = append(c.starting, func(c *Cmd) error {
+			var host string
+			for _, kv := range c.Env {
+				if strings.HasPrefix(kv, "SSH_AUTH_SOCK=") {
+					host = strings.TrimPrefix(kv, "SSH_AUTH_SOCK=")
+				}
+			}
+			if host == "" {
+				return errors.New("exec: ForwardSSHAgent: SSH_AUTH_SOCK not set")
+			}
+			guest := fmt.Sprintf("/tmp/pkg-exec-agent-%d-%d/agent.sock", os.Getpid(), atomic.AddUint32(&agentSeq, 1))
+			if err := forwardSocket(c, host, guest); err != nil {
+				return err
+			}
+			c.finished = append(c.finished, func(*Cmd) error {
+				return os.Remove(filepath.Dir(filepath.Join(chrootDir(c), guest)))
+			})
+			return Setenv("SSH_AUTH_SOCK", guest)(c)
+		})
+		return nil
+	})
+}
+
+func chrootDir(c *Cmd) string {
+	if c.SysProcAttr == nil {
+		return ""
+	}
+	return c.SysProcAttr.Chroot
+}
+
+// forwardSocket proxies connections to guest, within the child's root,
+// to host until the command has exited.
+func forwardSocket(c *Cmd, host, guest string) error {
+	path := filepath.Join("/", chrootDir(c), guest)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	var (
+		mu     sync.Mutex
+		conns  = map[net.Conn]bool{}
+		closed bool
+		wg     sync.WaitGroup
+	)
+	// track records conn so it is closed once the command has exited,
+	// closing it at once if that has already happened.
+	track := func(conn net.Conn) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			conn.Close()
+			return false
+		}
+		conns[conn] = true
+		return true
+	}
+	untrack := func(conn net.Conn) {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(conns, conn)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			if !track(conn) {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer conn.Close()
+				defer untrack(conn)
+				upstream, err := net.Dial("unix", host)
+				if err != nil || !track(upstream) {
+					return
+				}
+				defer upstream.Close()
+				defer untrack(upstream)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					io.Copy(upstream, conn)
+				}()
+				io.Copy(conn, upstream)
+			}()
+		}
+	}()
+	c.finished = append(c.finished, func(*Cmd) error {
+		err := l.Close() // also removes the socket
+		mu.Lock()
+		closed = true
+		for conn := range conns {
+			conn.Close()
+		}
+		mu.Unlock()
+		wg.Wait()
+		return err
+	})
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package exec
+
+// Askpass answers the credential prompts of the child by calling fn.
+// It is not supported on this platform.
+func Askpass(fn func(prompt string) (string, error)) func(*Cmd) error {
+	return Describe("Askpass", []Param{{"fn", fn}}, func(*Cmd) error {
+		return notSupported("Askpass")
+	})
+}
@@ -0,0 +1,110 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Askpass answers the credential prompts of the child, and of any git,
+// ssh or sudo commands it runs, by calling fn with the text of each
+// prompt. The child's SSH_ASKPASS, GIT_ASKPASS and SUDO_ASKPASS are set
+// to a small script which passes the prompt to fn through a pair of
+// named pipes and prints its answer, and SSH_ASKPASS_REQUIRE is set so
+// that ssh uses it even when a terminal is available. If fn returns an
+// error, the prompt is cancelled. Prompts are answered one at a time; fn
+// is called from another goroutine.
+func Askpass(fn func(prompt string) (string, error)) func(*Cmd) error {
+	return Describe("Askpass", []Param{{"fn", fn}}, func(c *Cmd) error {
+		dir, err := ioutil.TempDir("", "pkg-exec-askpass-")
+		if err != nil {
+			return err
+		}
+		prompt, reply := filepath.Join(dir, "prompt"), filepath.Join(dir, "reply")
+		script := filepath.Join(dir, "askpass")
+		for _, fifo := range []string{prompt, reply} {
+			if err := syscall.Mkfifo(fifo, 0600); err != nil {
+				os.RemoveAll(dir)
+				return fmt.Errorf("exec: Askpass: %v", err)
+			}
+		}
+		err = ioutil.WriteFile(script, []byte(fmt.Sprintf(`#!/bin/sh
+printf '%%s' "$1" > %s
+reply=$(cat %s)
+case $reply in
+1*) printf '%%s\n' "${reply#1}" ;;
+*) exit 1 ;;
+esac
+`, shellQuote(prompt), shellQuote(reply))), 0700)
+		if err != nil {
+			os.RemoveAll(dir)
+			return err
+		}
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				b, err := ioutil.ReadFile(prompt) // blocks until the script writes
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if err != nil {
+					return
+				}
+				answer, err := "", errors.New("exec: Askpass: callback panicked")
+				c.guard(func() { answer, err = fn(string(b)) })
+				if err != nil {
+					answer = "0"
+				} else {
+					answer = "1" + answer
+				}
+				ioutil.WriteFile(reply, []byte(answer), 0)
+			}
+		}()
+		c.finished = append(c.finished, func(*Cmd) error {
+			close(done)
+			for {
+				// wake the goroutine if it is blocked opening either pipe
+				// because the script exited without the other end.
+				if f, err := os.OpenFile(prompt, os.O_WRONLY|syscall.O_NONBLOCK, 0); err == nil {
+					f.Close()
+				}
+				if f, err := os.OpenFile(reply, os.O_RDONLY|syscall.O_NONBLOCK, 0); err == nil {
+					f.Close()
+				}
+				select {
+				case <-stopped:
+					return os.RemoveAll(dir)
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+		})
+		return applyOptions(c,
+			Setenv("SSH_ASKPASS", script),
+			Setenv("SSH_ASKPASS_REQUIRE", "force"),
+			Setenv("GIT_ASKPASS", script),
+			Setenv("SUDO_ASKPASS", script),
+		)
+	})
+}
+
+// shellQuote quotes s for use as a single word by /bin/sh.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,224 @@
+package exec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// A Backend runs commands other than directly on the local host, such
+// as on a remote host or in a container, by way of a local program such
+// as ssh. See BackendCommand.
+type Backend interface {
+	// Wrap returns the local command line, program first, which runs r
+	// on the backend.
+	Wrap(r *RemoteCommand) ([]string, error)
+}
+
+// RemoteCommand is a command to be run by a Backend.
+type RemoteCommand struct {
+	Args  []string // program, as named on the backend, and arguments
+	Dir   string   // working directory on the backend, or "" for its default
+	Env   []string // variables, as "key=value", added to the backend's environment
+	Unset []string // names of variables removed from the backend's environment
+	TTY   bool     // stdin is a terminal, so the command may be run in one
+	Stdin bool     // stdin is set, so must be forwarded to the command
+
+	// LocalEnv may be set by Wrap to variables, as "key=value", to add to
+	// the environment of the local program.
+	LocalEnv []string
+}
+
+// BackendCommand returns a Cmd to run the named program with the given
+// arguments on b. The program is not looked up locally, and the working
+// directory and environment variables set by options such as Dir and
+// Setenv apply on the backend, while the command's stdin, stdout and
+// stderr are those of the local program which b runs. Just before the
+// command starts, once its options have been applied, the command line
+// is replaced by that returned by b.Wrap, which is run locally in the
+// parent's environment; the policy set by SetPolicy is checked against
+// that local command line.
+//
+// Options which act on the arguments, environment and working directory
+// apply on the backend, as do those which act on output and time, such
+// as MaxOutput and IdleTimeout, while options which act on the process,
+// such as Nice and Cgroup, apply to the local program. Every variable
+// set by Setenv, or by an option which uses it such as Locale, is passed
+// to the backend even if the local value is the same, and every variable
+// removed by Unsetenv is removed there. Options which would run the
+// program under another local program, such as Elevated, Limit, Seccomp
+// and MacSandbox, or which refer to local files, such as Verify, TempDir
+// and FileArgs, cannot be honoured and are reported as errors by Start;
+// to apply them on the backend, see RemoteSpec.
+//
+//	cmd := exec.BackendCommand(&ssh.Host{Name: "build@ci"}, "make", "test")
+func BackendCommand(b Backend, name string, args ...string) *Cmd {
+	return &Cmd{
+		Cmd:        &exec.Cmd{Path: name, Args: append([]string{name}, args...)},
+		initalised: true,
+		backend:    b,
+	}
+}
+
+// specArg is the argument which precedes the JSON encoded Spec passed to
+// the agent run by RemoteSpec.
+const specArg = "-exec-spec"
+
+// RemoteSpec returns a Cmd to run the command described by s on b by way
+// of agent, a program on the backend which calls ServeSpec. s is encoded
+// as JSON, see Spec.MarshalJSON, and passed to agent as an argument, so
+// that its options, such as timeouts, limits, environment and output
+// policies, are applied on the backend rather than locally. Start
+// returns an error if s has options which cannot be encoded.
+//
+//	cmd := exec.RemoteSpec(&ssh.Host{Name: "build@ci"}, "/usr/local/bin/agent", spec)
+func RemoteSpec(b Backend, agent string, s Spec) *Cmd {
+	data, err := json.Marshal(s)
+	c := BackendCommand(b, agent, specArg, string(data))
+	if err != nil {
+		c.Err = err
+	}
+	return c
+}
+
+// ServeSpec runs the command passed by RemoteSpec, if args, usually
+// os.Args[1:], are those of an agent started by it, with the stdin,
+// stdout and stderr of the current process, and exits with its exit
+// code. If the command could not be decoded or started, ServeSpec prints
+// the error and exits with status 127. It returns if args are not those
+// of an agent, so should be called at the start of main.
+//
+//	func main() {
+//		exec.ServeSpec(os.Args[1:])
+//		...
+//	}
+func ServeSpec(args []string) {
+	if len(args) != 2 || args[0] != specArg {
+		return
+	}
+	var s Spec
+	if err := json.Unmarshal([]byte(args[1]), &s); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(127)
+	}
+	c := s.Command()
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err := c.Run()
+	if err == nil {
+		os.Exit(0)
+	}
+	if c.ProcessState == nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(127)
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if code := c.ProcessState.ExitCode(); code > 0 {
+		os.Exit(code)
+	}
+	os.Exit(1)
+}
+
+// localOptions are the options which BackendCommand cannot honour, as
+// they run the program under another local program or refer to local
+// files.
+var localOptions = map[string]bool{
+	"Askpass":          true,
+	"Capsicum":         true,
+	"Chroot":           true,
+	"DirCreate":        true,
+	"DiskQuota":        true,
+	"DropCapabilities": true,
+	"Elevated":         true,
+	"ExpandGlobs":      true,
+	"ExpandTilde":      true,
+	"FileArgs":         true,
+	"ForwardSSHAgent":  true,
+	"ForwardSocket":    true,
+	"Jail":             true,
+	"Landlock":         true,
+	"Limit":            true,
+	"Listeners":        true,
+	"MacSandbox":       true,
+	"OutputFiles":      true,
+	"OverlayRoot":      true,
+	"RecordManifest":   true,
+	"Seccomp":          true,
+	"TempDir":          true,
+	"Verify":           true,
+	"WASI":             true,
+}
+
+// checkBackendOptions returns an error if an option applied to a
+// BackendCommand cannot be honoured.
+func (c *Cmd) checkBackendOptions() error {
+	for _, o := range c.applied {
+		if localOptions[o.Name] {
+			return fmt.Errorf("exec: %s cannot be used with a BackendCommand", o.Name)
+		}
+	}
+	return nil
+}
+
+// wrapBackend replaces the command line with that which runs it on
+// the command's backend.
+func (c *Cmd) wrapBackend() error {
+	env, unset := c.optionEnv()
+	r := &RemoteCommand{
+		Args:  append([]string{c.Path}, c.Args[1:]...),
+		Dir:   c.Dir,
+		Env:   env,
+		Unset: unset,
+		TTY:   isTerminal(c.Stdin),
+		Stdin: c.Stdin != nil,
+	}
+	argv, err := c.backend.Wrap(r)
+	if err != nil {
+		return err
+	}
+	if len(argv) == 0 {
+		return errors.New("exec: backend returned an empty command line")
+	}
+	path, err := LookPath(argv[0])
+	if err != nil {
+		return err
+	}
+	c.Path, c.Args, c.Dir, c.Env = path, argv, "", nil
+	if len(r.LocalEnv) > 0 {
+		c.Env = append(os.Environ(), r.LocalEnv...)
+	}
+	return nil
+}
+
+// optionEnv returns the variables set by options, as "key=value", and
+// the names of those removed by options, whatever their values in the
+// parent's environment.
+func (c *Cmd) optionEnv() (env, unset []string) {
+	seen := make(map[string]bool)
+	for _, key := range c.envKeys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if v, ok := c.lookupEnv(key); ok {
+			env = append(env, key+"="+v)
+		} else {
+			unset = append(unset, key)
+		}
+	}
+	return env, unset
+}
+
+// isTerminal reports whether r is a terminal.
+func isTerminal(r interface{}) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
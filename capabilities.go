@@ -0,0 +1,31 @@
+package exec
+
+import "sync"
+
+// Features reports which of the platform dependent features used by
+// options in this package are available on the current host.
+type Features struct {
+	Cgroups    bool // cgroup v2 hierarchy mounted, see Cgroup
+	NetCls     bool // cgroup v1 net_cls controller mounted, see NetRateLimit
+	Namespaces bool // caller may create Linux namespaces
+	PTY        bool // pseudo terminals can be allocated
+	JobObjects bool // Windows job objects
+	Landlock   bool // Landlock LSM enabled
+	Seccomp    bool // seccomp filters, see Seccomp
+}
+
+var (
+	featuresOnce sync.Once
+	features     Features
+)
+
+// Capabilities probes the current host for the features some options
+// depend on, so callers can select fallbacks before running a command
+// rather than handling errors from Start. The result is computed once
+// and cached.
+func Capabilities() Features {
+	featuresOnce.Do(func() {
+		features = probeFeatures()
+	})
+	return features
+}
@@ -0,0 +1,46 @@
+package exec
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	sysLandlockCreateRuleset     = 444
+	landlockCreateRulesetVersion = 1 << 0
+	prGetSeccomp                 = 21
+)
+
+func probeFeatures() Features {
+	var f Features
+	if _, err := cgroup2Mount(); err == nil {
+		f.Cgroups = true
+	}
+	if _, err := cgroupMount("net_cls"); err == nil {
+		f.NetCls = true
+	}
+	if _, err := os.Stat("/proc/self/ns/pid"); err == nil {
+		f.Namespaces = os.Geteuid() == 0 || userNamespacesAllowed()
+	}
+	if tty, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0); err == nil {
+		tty.Close()
+		f.PTY = true
+	}
+	abi, _, errno := syscall.Syscall(sysLandlockCreateRuleset, 0, 0, landlockCreateRulesetVersion)
+	f.Landlock = errno == 0 && int(abi) > 0
+	_, _, errno = syscall.RawSyscall(syscall.SYS_PRCTL, prGetSeccomp, 0, 0)
+	f.Seccomp = errno == 0 && seccompSyscalls != nil
+	return f
+}
+
+func userNamespacesAllowed() bool {
+	b, err := ioutil.ReadFile("/proc/sys/user/max_user_namespaces")
+	if err != nil {
+		return false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	return err == nil && n > 0
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package exec
+
+import "os"
+
+func probeFeatures() Features {
+	var f Features
+	if tty, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0); err == nil {
+		tty.Close()
+		f.PTY = true
+	}
+	return f
+}
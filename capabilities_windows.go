@@ -0,0 +1,13 @@
+package exec
+
+import "syscall"
+
+func probeFeatures() Features {
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+	f := Features{PTY: kernel32.NewProc("CreatePseudoConsole").Find() == nil}
+	if h, _, _ := kernel32.NewProc("CreateJobObjectW").Call(0, 0); h != 0 {
+		syscall.CloseHandle(syscall.Handle(h))
+		f.JobObjects = true
+	}
+	return f
+}
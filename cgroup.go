@@ -0,0 +1,9 @@
+package exec
+
+// CgroupConfig describes the limits enforced by Cgroup. A zero field
+// leaves the corresponding resource unlimited.
+type CgroupConfig struct {
+	CPUMax    float64 // CPUs worth of time per period, for example 0.5
+	MemoryMax int64   // bytes of memory, including the page cache
+	PidsMax   int64   // processes and threads
+}
@@ -0,0 +1,211 @@
+package exec
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// cgroupSeq distinguishes the cgroups created by Cgroup.
+var cgroupSeq uint32
+
+// cgroupPeriod is the cpu.max period, in microseconds.
+const cgroupPeriod = 100000
+
+// Cgroup runs the child in a transient cgroup v2 group enforcing cfg.
+//
+// The group is created below the caller's own group in the unified
+// hierarchy, such as a group delegated to it by systemd, which must have
+// the controllers cfg needs enabled in cgroup.subtree_control, and the
+// caller must be permitted to write to it. The child is created
+// directly in the group, with CLONE_INTO_CGROUP, so it is limited from
+// its first instruction; that requires Linux 5.7 or later. Once the
+// command has exited any processes left in the group are killed and the
+// group is removed.
+func Cgroup(cfg CgroupConfig) func(*Cmd) error {
+	return Describe("Cgroup", []Param{{"cfg", cfg}}, func(c *Cmd) error {
+		if cfg.CPUMax < 0 || cfg.MemoryMax < 0 || cfg.PidsMax < 0 {
+			return errors.New("exec: Cgroup limits must not be negative")
+		}
+		parent, err := ownCgroup2()
+		if err != nil {
+			return err
+		}
+		dir := filepath.Join(parent, fmt.Sprintf("pkg-exec-%d-%d", os.Getpid(), atomic.AddUint32(&cgroupSeq, 1)))
+		limits := map[string]string{}
+		if cfg.CPUMax > 0 {
+			limits["cpu.max"] = fmt.Sprintf("%d %d", int64(cfg.CPUMax*cgroupPeriod), cgroupPeriod)
+		}
+		if cfg.MemoryMax > 0 {
+			limits["memory.max"] = strconv.FormatInt(cfg.MemoryMax, 10)
+		}
+		if cfg.PidsMax > 0 {
+			limits["pids.max"] = strconv.FormatInt(cfg.PidsMax, 10)
+		}
+		b, err := ioutil.ReadFile(filepath.Join(parent, "cgroup.subtree_control"))
+		if err != nil {
+			return err
+		}
+		enabled := strings.Fields(string(b))
+		for file := range limits {
+			if controller := strings.Split(file, ".")[0]; !contains(enabled, controller) {
+				return fmt.Errorf("exec: Cgroup: %s controller %w: not enabled", controller, ErrNotSupported)
+			}
+		}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			if c.SysProcAttr == nil {
+				c.SysProcAttr = &syscall.SysProcAttr{}
+			}
+			if c.SysProcAttr.UseCgroupFD {
+				return errors.New("exec: Cgroup cannot be used with SysProcAttr.UseCgroupFD")
+			}
+			if err := os.Mkdir(dir, 0755); err != nil {
+				return err
+			}
+			c.finished = append(c.finished, func(*Cmd) error {
+				writeFile(filepath.Join(dir, "cgroup.kill"), "1") // Linux 5.14 and later
+				waitCgroupEmpty(dir)
+				return os.Remove(dir)
+			})
+			for file, limit := range limits {
+				if err := writeFile(filepath.Join(dir, file), limit); err != nil {
+					return fmt.Errorf("exec: Cgroup: %v", err)
+				}
+			}
+			f, err := os.Open(dir)
+			if err != nil {
+				return err
+			}
+			// the descriptor is only needed until the child is created.
+			c.started = append(c.started, func(*Cmd) error { return f.Close() })
+			c.finished = append(c.finished, func(*Cmd) error {
+				f.Close()
+				return nil
+			})
+			c.SysProcAttr.UseCgroupFD = true
+			c.SysProcAttr.CgroupFD = int(f.Fd())
+			return nil
+		})
+		return nil
+	})
+}
+
+// cgroupDrainTimeout bounds how long Cgroup waits for the processes left
+// in a group to exit before removing it.
+const cgroupDrainTimeout = 5 * time.Second
+
+// waitCgroupEmpty waits until no processes remain in the group at dir,
+// as cgroup.kill only signals them, or until cgroupDrainTimeout passes.
+func waitCgroupEmpty(dir string) {
+	deadline := time.Now().Add(cgroupDrainTimeout)
+	for time.Now().Before(deadline) {
+		b, err := ioutil.ReadFile(filepath.Join(dir, "cgroup.events"))
+		if err != nil || strings.Contains(string(b), "populated 0") {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// ownCgroup2 returns the directory of the caller's cgroup in the v2
+// unified hierarchy.
+func ownCgroup2() (string, error) {
+	root, err := cgroup2Mount()
+	if err != nil {
+		return "", err
+	}
+	b, err := ioutil.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		// 0::/user.slice/user-1000.slice/session-2.scope
+		if strings.HasPrefix(line, "0::") {
+			return filepath.Join(root, line[len("0::"):]), nil
+		}
+	}
+	return "", fmt.Errorf("exec: cgroup2 group of the current process %w: not found", ErrNotSupported)
+}
+
+// mount is an entry from /proc/self/mountinfo.
+type mount struct {
+	point   string
+	fstype  string
+	options []string // super options
+}
+
+func mounts() ([]mount, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var ms []mount
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		// 36 35 0:30 / /sys/fs/cgroup/net_cls rw,relatime - cgroup cgroup rw,net_cls
+		fields := strings.Fields(sc.Text())
+		for i := range fields {
+			if fields[i] == "-" && i+3 < len(fields) && len(fields) > 4 {
+				ms = append(ms, mount{
+					point:   fields[4],
+					fstype:  fields[i+1],
+					options: strings.Split(fields[i+3], ","),
+				})
+				break
+			}
+		}
+	}
+	return ms, sc.Err()
+}
+
+// cgroupMount returns the mount point of the cgroup v1 hierarchy
+// carrying controller.
+func cgroupMount(controller string) (string, error) {
+	ms, err := mounts()
+	if err != nil {
+		return "", err
+	}
+	for _, m := range ms {
+		if m.fstype != "cgroup" {
+			continue
+		}
+		for _, opt := range m.options {
+			if opt == controller {
+				return m.point, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("exec: %s cgroup controller %w: not mounted", controller, ErrNotSupported)
+}
+
+// cgroup2Mount returns the mount point of the cgroup v2 unified hierarchy.
+func cgroup2Mount() (string, error) {
+	ms, err := mounts()
+	if err != nil {
+		return "", err
+	}
+	for _, m := range ms {
+		if m.fstype == "cgroup2" {
+			return m.point, nil
+		}
+	}
+	return "", fmt.Errorf("exec: cgroup2 %w: not mounted", ErrNotSupported)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package exec
+
+// Cgroup runs the child in a transient cgroup enforcing cfg.
+// It is only supported on Linux.
+func Cgroup(cfg CgroupConfig) func(*Cmd) error {
+	return Describe("Cgroup", []Param{{"cfg", cfg}}, func(*Cmd) error {
+		return notSupported("Cgroup")
+	})
+}
@@ -0,0 +1,12 @@
+//go:build windows || plan9 || js || wasip1
+// +build windows plan9 js wasip1
+
+package exec
+
+// Chroot runs the child with dir as its root directory.
+// It is not supported on Windows, Plan 9, js or wasip1.
+func Chroot(dir string) func(*Cmd) error {
+	return Describe("Chroot", []Param{{"dir", dir}}, func(*Cmd) error {
+		return notSupported("Chroot")
+	})
+}
@@ -0,0 +1,43 @@
+//go:build !windows && !plan9 && !js && !wasip1
+// +build !windows,!plan9,!js,!wasip1
+
+package exec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Chroot runs the child with dir as its root directory. The program,
+// and Dir if set, are interpreted relative to the new root; the command
+// fails to start if the program does not exist there. Changing the root
+// directory requires CAP_SYS_CHROOT.
+func Chroot(dir string) func(*Cmd) error {
+	return Describe("Chroot", []Param{{"dir", dir}}, func(c *Cmd) error {
+		root, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		if c.SysProcAttr == nil {
+			c.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		c.SysProcAttr.Chroot = root
+		c.starting = append(c.starting, func(c *Cmd) error {
+			path := c.Path
+			if !filepath.IsAbs(path) {
+				path = filepath.Join("/", c.Dir, path)
+			}
+			fi, err := os.Stat(filepath.Join(root, path))
+			switch {
+			case err != nil:
+				return fmt.Errorf("exec: Chroot: %s not found in %s: %w", path, root, err)
+			case !fi.Mode().IsRegular() || fi.Mode()&0111 == 0:
+				return fmt.Errorf("exec: Chroot: %s in %s is not an executable file", path, root)
+			}
+			return nil
+		})
+		return nil
+	})
+}
@@ -0,0 +1,155 @@
+package exec
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// Batch runs a command over a list of items, such as file names, as
+// xargs does, appending as many items to each invocation as fit on its
+// command line. See Chunked.
+type Batch struct {
+	// Args are the program and the arguments preceding the items in
+	// each invocation.
+	Args  []string
+	Items []string
+
+	// Parallelism limits the number of invocations run at once. If it
+	// is zero or one, they are run one at a time, in order.
+	Parallelism int
+
+	// MaxArgLen limits the size, in bytes, of each command line. The
+	// default is the smallest limit the platform guarantees, less the
+	// size of the current environment, which counts against it.
+	MaxArgLen int
+}
+
+// Chunked returns a Batch running the program baseArgs[0] with the
+// remaining baseArgs followed by as many of items as fit on its command
+// line, as many times as needed to pass all the items.
+//
+//	err := exec.Chunked([]string{"rm", "--"}, files).Run()
+func Chunked(baseArgs []string, items []string) *Batch {
+	return &Batch{Args: baseArgs, Items: items}
+}
+
+// Chunks returns the items split into the lists passed to each
+// invocation. An item too long to fit with the Args is passed on its
+// own.
+func (b *Batch) Chunks() [][]string {
+	max := b.MaxArgLen
+	if max <= 0 {
+		max = defaultMaxArgLen()
+	}
+	base := 0
+	for _, arg := range b.Args {
+		base += argLen(arg)
+	}
+	var chunks [][]string
+	var chunk []string
+	size := base
+	for _, item := range b.Items {
+		if len(chunk) > 0 && size+argLen(item) > max {
+			chunks = append(chunks, chunk)
+			chunk, size = nil, base
+		}
+		chunk = append(chunk, item)
+		size += argLen(item)
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// Run runs an invocation for each chunk of items, with opts, and waits
+// for them to exit. The error of each invocation which failed is
+// returned, as a MultiError if there are several. Nothing is run if
+// there are no items.
+func (b *Batch) Run(opts ...func(*Cmd) error) error {
+	_, err := b.run(false, opts)
+	return err
+}
+
+// Output runs the batch as Run does, and returns the standard output of
+// the invocations, concatenated in the order of their chunks.
+func (b *Batch) Output(opts ...func(*Cmd) error) ([]byte, error) {
+	return b.run(true, opts)
+}
+
+func (b *Batch) run(output bool, opts []func(*Cmd) error) ([]byte, error) {
+	if len(b.Args) == 0 {
+		return nil, errors.New("exec: Batch has no program")
+	}
+	chunks := b.Chunks()
+	outs := make([][]byte, len(chunks))
+	errs := make([]error, len(chunks))
+	n := b.Parallelism
+	if n < 1 {
+		n = 1
+	}
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, chunk []string) {
+			defer func() { <-sem; wg.Done() }()
+			args := append(append([]string(nil), b.Args[1:]...), chunk...)
+			cmd := Command(b.Args[0], args...)
+			if output {
+				outs[i], errs[i] = cmd.Output(opts...)
+			} else {
+				errs[i] = cmd.Run(opts...)
+			}
+		}(i, chunk)
+	}
+	wg.Wait()
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	return bytes.Join(outs, nil), joinErrors(failed)
+}
+
+// argLen returns the space arg takes on a command line.
+func argLen(arg string) int {
+	if runtime.GOOS == "windows" {
+		return len(arg) + 3 // quotes and a space
+	}
+	return len(arg) + 1 + 8 // NUL and a pointer in argv
+}
+
+// headroom is left on command lines for the variables options add to
+// the environment.
+const headroom = 2048
+
+// defaultMaxArgLen returns the size of command lines Batch uses by
+// default.
+func defaultMaxArgLen() int {
+	switch runtime.GOOS {
+	case "windows":
+		// CreateProcess limits the command line to 32767 UTF-16 code
+		// units, and the environment is separate.
+		return 32767 - headroom
+	case "linux":
+		// ARG_MAX is a quarter of the stack limit, but at least 128KiB.
+		return 128<<10 - envLen() - headroom
+	case "darwin", "freebsd", "netbsd", "openbsd", "dragonfly":
+		return 256<<10 - envLen() - headroom
+	}
+	return 4096 // _POSIX_ARG_MAX
+}
+
+func envLen() int {
+	n := 0
+	for _, kv := range os.Environ() {
+		n += argLen(kv)
+	}
+	return n
+}
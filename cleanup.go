@@ -0,0 +1,49 @@
+package exec
+
+import "fmt"
+
+// Cleanup runs the command described by spec once the command has
+// exited, whether it succeeded, failed, or was killed, as a shell's
+// "trap ... EXIT" would. It suits paired operations such as mount and
+// umount, or setting up and tearing down a port forward. The cleanup
+// runs from Wait, so Wait must be called, as it must be anyway; it also
+// runs if the command, or an option, fails to start. An error from the
+// cleanup is returned by Wait if the command itself succeeded.
+func Cleanup(spec Spec) func(*Cmd) error {
+	return Describe("Cleanup", []Param{{"spec", spec}}, func(c *Cmd) error {
+		c.finished = append(c.finished, func(*Cmd) error {
+			return runStep("cleanup", spec)
+		})
+		return nil
+	})
+}
+
+// WithSetup runs setup, then fn, then teardown, for resources created
+// by external tools around a block of work, such as a tunnel or a
+// temporary cluster. fn is not called if setup fails, but teardown runs
+// whenever setup was started, even if setup or fn failed or fn panicked,
+// as a partly completed setup may need tearing down too. The error from
+// setup or fn is returned, or else that from teardown.
+func WithSetup(setup, teardown Spec, fn func() error) (err error) {
+	cmd := setup.Command()
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("exec: setup %s: %w", setup.Name, err)
+	}
+	defer func() {
+		if errTeardown := runStep("teardown", teardown); err == nil {
+			err = errTeardown
+		}
+	}()
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("exec: setup %s: %w", setup.Name, err)
+	}
+	return fn()
+}
+
+// runStep runs spec, describing it as the given step in any error.
+func runStep(step string, spec Spec) error {
+	if err := spec.Command().Run(); err != nil {
+		return fmt.Errorf("exec: %s %s: %w", step, spec.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package exec
+
+import "time"
+
+// Clock is a source of time, so that timeouts, backoff and the durations
+// this package reports may be tested deterministically, see UseClock.
+// exectest.FakeClock is a Clock controlled by the test.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f in its own goroutine once d has elapsed, as
+	// time.AfterFunc.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is an event scheduled by a Clock. *time.Timer is a Timer.
+type Timer interface {
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// UseClock makes the command use clk for its timeouts, such as
+// IdleTimeout and the grace period of Stop, and for the times and
+// durations reported by Usage, Transcript and RecordManifest. The
+// default is the system clock.
+func UseClock(clk Clock) func(*Cmd) error {
+	return Describe("UseClock", []Param{{"clk", clk}}, func(c *Cmd) error {
+		c.clock = clk
+		return nil
+	})
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
+
+// clk returns the command's Clock.
+func (c *Cmd) clk() Clock {
+	if c.clock == nil {
+		return systemClock{}
+	}
+	return c.clock
+}
+
+// after returns a channel which is closed once d has elapsed on clk, and
+// a function which stops the timer.
+func after(clk Clock, d time.Duration) (<-chan struct{}, func() bool) {
+	ch := make(chan struct{})
+	t := clk.AfterFunc(d, func() { close(ch) })
+	return ch, t.Stop
+}
@@ -0,0 +1,51 @@
+package exec
+
+// Container is a Backend which runs commands in a running container
+// with the exec command of a container runtime's CLI, such as docker
+// exec, see InContainer.
+type Container struct {
+	ID      string // container name or ID
+	Runtime string // CLI of the container runtime, "docker" if empty; "podman" and "nerdctl" are compatible
+	User    string // user to run commands as, or "" for the container's default
+}
+
+// InContainer returns a Backend running commands in the container id
+// with docker exec.
+//
+//	out, err := exec.InContainer("web").Command("ls", "/srv").Output(exec.Dir("/srv"))
+func InContainer(id string) *Container {
+	return &Container{ID: id}
+}
+
+// Command returns a Cmd to run the named program with the given
+// arguments in the container, see BackendCommand.
+func (ct *Container) Command(name string, args ...string) *Cmd {
+	return BackendCommand(ct, name, args...)
+}
+
+// Wrap returns the command line which runs r in the container. Stdin
+// is forwarded if it is set, and a terminal allocated if it is one.
+func (ct *Container) Wrap(r *RemoteCommand) ([]string, error) {
+	cli := ct.Runtime
+	if cli == "" {
+		cli = "docker"
+	}
+	argv := []string{cli, "exec"}
+	if r.Stdin {
+		argv = append(argv, "-i")
+	}
+	if r.TTY {
+		argv = append(argv, "-t")
+	}
+	if ct.User != "" {
+		argv = append(argv, "-u", ct.User)
+	}
+	if r.Dir != "" {
+		argv = append(argv, "-w", r.Dir)
+	}
+	for _, kv := range r.Env {
+		argv = append(argv, "-e", kv)
+	}
+	argv = append(argv, ct.ID)
+	return append(argv, r.Args...), nil
+}
@@ -0,0 +1,59 @@
+package exec
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrCPULimit is returned by Wait when the command was killed by
+// CPULimit.
+var ErrCPULimit = errors.New("exec: CPU time limit exceeded")
+
+// CPULimit kills the command once it has used more than d of CPU time,
+// however long it has run. Unlike a deadline, time the child spends
+// sleeping or blocked does not count. The child's CPU time is sampled
+// every twentieth of d, but at most every 10ms and at least every
+// second, so a child running n threads at once may exceed d by up to n
+// sampling intervals. Only the child itself is measured, not its
+// descendants. It is only supported on Linux.
+func CPULimit(d time.Duration) func(*Cmd) error {
+	return Describe("CPULimit", []Param{{"d", d}}, func(c *Cmd) error {
+		if !monitorSupported {
+			return notSupported("CPULimit")
+		}
+		if d <= 0 {
+			return errors.New("exec: CPULimit must be positive")
+		}
+		interval := d / 20
+		switch {
+		case interval < 10*time.Millisecond:
+			interval = 10 * time.Millisecond
+		case interval > time.Second:
+			interval = time.Second
+		}
+		done := make(chan struct{})
+		c.started = append(c.started, func(c *Cmd) error {
+			go func() {
+				t := time.NewTicker(interval)
+				defer t.Stop()
+				for {
+					select {
+					case <-done:
+						return
+					case <-t.C:
+					}
+					if _, cpu, err := sampleProcess(c.Process.Pid); err == nil && cpu > d {
+						c.kill(ErrCPULimit)
+						return
+					}
+				}
+			}()
+			c.finished = append(c.finished, func(*Cmd) error {
+				close(done)
+				return nil
+			})
+			return nil
+		})
+		return nil
+	})
+}
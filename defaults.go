@@ -0,0 +1,48 @@
+package exec
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var registry struct {
+	sync.Mutex
+	defaults map[string][]func(*Cmd) error
+}
+
+// RegisterDefaults registers opts to be applied to every command which
+// runs program, replacing any registered before. With no opts, the
+// defaults for program are removed. program may be an absolute path, or
+// a name matching the base name of the program with any .exe extension
+// removed.
+//
+// Defaults are applied before all other options, so options passed to
+// Start, or set in a Spec, override them.
+//
+//	exec.RegisterDefaults("python", exec.Setenv("PYTHONUNBUFFERED", "1"))
+func RegisterDefaults(program string, opts ...func(*Cmd) error) {
+	registry.Lock()
+	defer registry.Unlock()
+	if len(opts) == 0 {
+		delete(registry.defaults, program)
+		return
+	}
+	if registry.defaults == nil {
+		registry.defaults = make(map[string][]func(*Cmd) error)
+	}
+	registry.defaults[program] = append([]func(*Cmd) error(nil), opts...)
+}
+
+// registeredDefaults returns the defaults registered for the program
+// at path, those registered by name first.
+func registeredDefaults(path string) []func(*Cmd) error {
+	registry.Lock()
+	defer registry.Unlock()
+	name := strings.TrimSuffix(filepath.Base(path), ".exe")
+	opts := registry.defaults[name]
+	if filepath.IsAbs(path) {
+		opts = append(opts[:len(opts):len(opts)], registry.defaults[path]...)
+	}
+	return opts
+}
@@ -0,0 +1,113 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Param is a named argument to the function which returned an option.
+type Param struct {
+	Name  string
+	Value interface{}
+}
+
+// AppliedOption describes an option applied to a command, see
+// Cmd.AppliedOptions.
+type AppliedOption struct {
+	Name   string  // the function which returned the option, such as "Setenv"
+	Params []Param // the arguments passed to it, in order
+}
+
+// String formats o as a call of the function which returned it, such as
+// `Setenv("TZ", "UTC")`. Functions, pointers and maps are shown by type
+// rather than value.
+func (o AppliedOption) String() string {
+	args := make([]string, len(o.Params))
+	for i, p := range o.Params {
+		args[i] = formatParam(reflect.ValueOf(p.Value))
+	}
+	return o.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+func formatParam(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+	if f, ok := v.Interface().(*os.File); ok && f != nil {
+		return f.Name()
+	}
+	switch v.Kind() {
+	case reflect.Func:
+		return "func"
+	case reflect.Ptr, reflect.Map, reflect.Chan, reflect.UnsafePointer:
+		return v.Type().String()
+	case reflect.Interface:
+		if v.IsNil() {
+			return "nil"
+		}
+		return formatParam(v.Elem())
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Slice, reflect.Array:
+		elems := make([]string, v.Len())
+		for i := range elems {
+			elems[i] = formatParam(v.Index(i))
+		}
+		return "[" + strings.Join(elems, " ") + "]"
+	case reflect.Struct:
+		fields := make([]string, v.NumField())
+		for i := range fields {
+			fields[i] = v.Type().Field(i).Name + ":" + formatParam(v.Field(i))
+		}
+		return "{" + strings.Join(fields, " ") + "}"
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// Describe returns opt, recording name and params in the command's
+// AppliedOptions when it is applied. Every option in this package is
+// described, and options defined elsewhere may use Describe so that
+// tools which render a command's configuration, such as config loaders
+// or user interfaces, can show them too.
+//
+//	func Verbose(level int) func(*exec.Cmd) error {
+//		return exec.Describe("Verbose", []exec.Param{{"level", level}}, func(c *exec.Cmd) error {
+//			c.Args = append(c.Args, "-v="+strconv.Itoa(level))
+//			return nil
+//		})
+//	}
+func Describe(name string, params []Param, opt func(*Cmd) error) func(*Cmd) error {
+	return func(c *Cmd) error {
+		c.mu.Lock()
+		c.applied = append(c.applied, AppliedOption{Name: name, Params: params})
+		c.mu.Unlock()
+		if c.describing {
+			return nil
+		}
+		return opt(c)
+	}
+}
+
+// AppliedOptions returns the described options applied to the command
+// so far, in the order they were applied, see Describe. Options applied
+// by another option, such as that passed to BestEffort, are listed after
+// it, and options passed to Resolved are listed once the program has
+// been resolved. Options which are not described are not listed. The
+// secrets set by RedactEnv and RedactArgs are masked in their parameters.
+func (c *Cmd) AppliedOptions() []AppliedOption {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	applied := make([]AppliedOption, len(c.applied))
+	for i, o := range c.applied {
+		applied[i] = AppliedOption{Name: o.Name, Params: c.redactParams(o.Params)}
+	}
+	return applied
+}
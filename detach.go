@@ -0,0 +1,52 @@
+package exec
+
+import (
+	"errors"
+	"os"
+)
+
+// Detach starts the command as a daemon, fully detached from the
+// current process, and returns its process ID. The child is not a child
+// of the current process, so it must not be waited for, and it continues
+// to run after the current process exits.
+//
+// On Unix the child is started with a double fork through /bin/sh, in a
+// new session it does not lead, so it cannot acquire a controlling
+// terminal. On Windows it is created with DETACHED_PROCESS, without a
+// console.
+//
+// Stdin, stdout and stderr are connected to the null device unless set
+// to files, and the child inherits ExtraFiles. Detach reports an error
+// if they are set to anything other than an *os.File, as nothing would
+// remain to copy to or from them, or if an option acts once the child
+// has started or exited. On Unix Argv0 cannot be honoured through the
+// shell, and is also reported as an error.
+func (c *Cmd) Detach(opts ...func(*Cmd) error) (int, error) {
+	if c.Process != nil {
+		return 0, errors.New("exec: already started")
+	}
+	pid, err := c.startDetached(opts...)
+	if err != nil {
+		// release anything acquired by options which were applied.
+		c.runFinished()
+	}
+	return pid, err
+}
+
+func (c *Cmd) startDetached(opts ...func(*Cmd) error) (int, error) {
+	if err := c.configure(opts...); err != nil {
+		return 0, err
+	}
+	if err := c.resolve(); err != nil {
+		return 0, err
+	}
+	for _, s := range []interface{}{c.Stdin, c.Stdout, c.Stderr} {
+		if _, ok := s.(*os.File); s != nil && !ok {
+			return 0, errors.New("exec: Detach can only connect stdio to files")
+		}
+	}
+	if len(c.started) > 0 || len(c.finished) > 0 {
+		return 0, errors.New("exec: Detach cannot run options which act after the program starts")
+	}
+	return c.detach()
+}
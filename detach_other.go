@@ -0,0 +1,8 @@
+//go:build plan9 || js || wasip1
+// +build plan9 js wasip1
+
+package exec
+
+func (c *Cmd) detach() (int, error) {
+	return 0, notSupported("Detach")
+}
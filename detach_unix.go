@@ -0,0 +1,62 @@
+//go:build !windows && !plan9 && !js && !wasip1
+// +build !windows,!plan9,!js,!wasip1
+
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// detach runs the program in the background of a shell in a new session,
+// which writes its process ID to a pipe and exits. The shell's stdin is
+// duplicated and redirected to the program explicitly, as the shell
+// connects that of a background job to /dev/null.
+func (c *Cmd) detach() (int, error) {
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	if c.SysProcAttr.Setpgid {
+		return 0, errors.New("exec: Detach cannot be used with Setpgid")
+	}
+	c.SysProcAttr.Setsid = true
+	for _, o := range c.applied {
+		if o.Name == "Argv0" {
+			return 0, errors.New("exec: Detach cannot be used with Argv0")
+		}
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	fd := 3 + len(c.ExtraFiles)
+	c.ExtraFiles = append(c.ExtraFiles, w)
+	script := fmt.Sprintf(`p=$1; shift; exec %[2]d<&0; "$p" "$@" <&%[2]d %[2]d<&- %[1]d>&- & echo $! >&%[1]d`, fd, fd+1)
+	program, args := c.Path, c.Args[1:]
+	c.Path = "/bin/sh"
+	c.Args = append([]string{"sh", "-c", script, "sh", program}, args...)
+	err = startChild(c, c.Cmd.Start)
+	w.Close()
+	if err != nil {
+		return 0, err
+	}
+	out, errRead := ioutil.ReadAll(r)
+	if err := waitChild(c); err != nil {
+		return 0, err
+	}
+	if errRead != nil {
+		return 0, errRead
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return 0, fmt.Errorf("exec: Detach: bad process ID %q from shell", out)
+	}
+	return pid, nil
+}
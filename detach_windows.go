@@ -0,0 +1,21 @@
+package exec
+
+import "syscall"
+
+// Process creation flags used by Detach.
+const (
+	detachedProcess       = 0x00000008
+	createNewProcessGroup = 0x00000200
+)
+
+func (c *Cmd) detach() (int, error) {
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.SysProcAttr.CreationFlags |= detachedProcess | createNewProcessGroup
+	if err := c.Cmd.Start(); err != nil {
+		return 0, err
+	}
+	pid := c.Process.Pid
+	return pid, c.Process.Release()
+}
@@ -0,0 +1,199 @@
+package exec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Diagnostic is a problem reported by a linter or compiler.
+type Diagnostic struct {
+	File     string
+	Line     int    // 1 based, 0 if unknown
+	Column   int    // 1 based, 0 if unknown
+	Severity string // "error", "warning", or "note"
+	Message  string
+	Rule     string // the rule or check which reported the problem, if known
+}
+
+// DiagnosticParser extracts a Diagnostic from a line of output, reporting
+// whether the line held one.
+type DiagnosticParser func(line string) (Diagnostic, bool)
+
+// Diagnostics passes each Diagnostic found by parse in the lines the
+// child writes to stdout and stderr to fn, as they are written. The
+// output is still passed to any writers set by Stdout or Stderr. Calls
+// to fn are serialised.
+func Diagnostics(parse DiagnosticParser, fn func(Diagnostic)) func(*Cmd) error {
+	return Describe("Diagnostics", []Param{{"parse", parse}, {"fn", fn}}, func(c *Cmd) error {
+		var mu sync.Mutex
+		found := writerFunc(func(p []byte) (int, error) {
+			c.guard(func() {
+				if d, ok := parse(strings.TrimRight(string(p), "\r\n")); ok {
+					mu.Lock()
+					defer mu.Unlock()
+					fn(d)
+				}
+			})
+			return len(p), nil
+		})
+		var lws []*lineWriter
+		c.starting = append(c.starting, func(c *Cmd) error {
+			wrapOutput(c, func(w io.Writer) io.Writer {
+				lw := &lineWriter{w: found, prefix: func() string { return "" }}
+				lws = append(lws, lw)
+				return io.MultiWriter(w, lw)
+			})
+			return nil
+		})
+		c.finished = append(c.finished, func(c *Cmd) error {
+			for _, lw := range lws {
+				lw.flush()
+			}
+			// a final unterminated line may have made fn panic.
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return c.killed
+		})
+		return nil
+	})
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (fn writerFunc) Write(p []byte) (int, error) { return fn(p) }
+
+var gnuDiagnostic = regexp.MustCompile(`^([^:\s][^:]*):(\d+)(?::(\d+))?:\s*(?:(error|warning|note|info):\s*)?(.+)$`)
+
+// trailingRule matches the "(rule)" suffix golangci-lint and others add.
+var trailingRule = regexp.MustCompile(`\s+\(([\w./-]+)\)$`)
+
+// ParseGNUDiagnostic parses lines in the "file:line:column: message" form
+// used by the go tool, go vet, golangci-lint, gcc and many others. The
+// column and a severity before the message are optional, and a rule name
+// in parentheses at the end of the message is recorded in Rule. The
+// severity is "error" unless the line says otherwise.
+func ParseGNUDiagnostic(line string) (Diagnostic, bool) {
+	m := gnuDiagnostic.FindStringSubmatch(line)
+	if m == nil {
+		return Diagnostic{}, false
+	}
+	d := Diagnostic{File: m[1], Severity: "error", Message: m[5]}
+	d.Line, _ = strconv.Atoi(m[2])
+	d.Column, _ = strconv.Atoi(m[3])
+	switch m[4] {
+	case "warning":
+		d.Severity = "warning"
+	case "note", "info":
+		d.Severity = "note"
+	}
+	if r := trailingRule.FindStringSubmatch(d.Message); r != nil {
+		d.Rule = r[1]
+		d.Message = strings.TrimSuffix(d.Message, r[0])
+	}
+	return d, true
+}
+
+var eslintDiagnostic = regexp.MustCompile(`^(.+): line (\d+), col (\d+), (Error|Warning) - (.+?)(?: \(([\w@./-]+)\))?$`)
+
+// ParseESLintDiagnostic parses lines written by eslint's compact
+// formatter, "file: line 1, col 2, Error - message (rule)".
+func ParseESLintDiagnostic(line string) (Diagnostic, bool) {
+	m := eslintDiagnostic.FindStringSubmatch(line)
+	if m == nil {
+		return Diagnostic{}, false
+	}
+	d := Diagnostic{File: m[1], Severity: strings.ToLower(m[4]), Message: m[5], Rule: m[6]}
+	d.Line, _ = strconv.Atoi(m[2])
+	d.Column, _ = strconv.Atoi(m[3])
+	return d, true
+}
+
+// GitHubAnnotations returns a function, for Diagnostics, which writes each
+// Diagnostic to w as a GitHub Actions workflow command, so it is shown
+// as an annotation on the affected line.
+func GitHubAnnotations(w io.Writer) func(Diagnostic) {
+	return func(d Diagnostic) {
+		level := d.Severity
+		if level == "note" {
+			level = "notice"
+		}
+		props := []string{"file=" + escapeAnnotationProperty(d.File)}
+		if d.Line > 0 {
+			props = append(props, "line="+strconv.Itoa(d.Line))
+		}
+		if d.Column > 0 {
+			props = append(props, "col="+strconv.Itoa(d.Column))
+		}
+		if d.Rule != "" {
+			props = append(props, "title="+escapeAnnotationProperty(d.Rule))
+		}
+		fmt.Fprintf(w, "::%s %s::%s\n", level, strings.Join(props, ","), escapeAnnotationData(d.Message))
+	}
+}
+
+func escapeAnnotationData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeAnnotationProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// WriteSARIF writes diags to w as a SARIF 2.1.0 log with a single run of
+// the named tool.
+func WriteSARIF(w io.Writer, tool string, diags []Diagnostic) error {
+	type region struct {
+		StartLine   int `json:"startLine,omitempty"`
+		StartColumn int `json:"startColumn,omitempty"`
+	}
+	type location struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region *region `json:"region,omitempty"`
+		} `json:"physicalLocation"`
+	}
+	type message struct {
+		Text string `json:"text"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId,omitempty"`
+		Level     string     `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations"`
+	}
+	results := make([]result, 0, len(diags))
+	for _, d := range diags {
+		var loc location
+		loc.PhysicalLocation.ArtifactLocation.URI = d.File
+		if d.Line > 0 {
+			loc.PhysicalLocation.Region = &region{StartLine: d.Line, StartColumn: d.Column}
+		}
+		results = append(results, result{
+			RuleID:    d.Rule,
+			Level:     d.Severity,
+			Message:   message{d.Message},
+			Locations: []location{loc},
+		})
+	}
+	type driver struct {
+		Name string `json:"name"`
+	}
+	log := map[string]interface{}{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []interface{}{map[string]interface{}{
+			"tool":    map[string]interface{}{"driver": driver{tool}},
+			"results": results,
+		}},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
@@ -0,0 +1,80 @@
+package exec_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pkg/exec"
+)
+
+func TestParseGNUDiagnostic(t *testing.T) {
+	tests := []struct {
+		line string
+		want exec.Diagnostic
+		ok   bool
+	}{
+		{"main.go:12:5: undefined: foo", exec.Diagnostic{File: "main.go", Line: 12, Column: 5, Severity: "error", Message: "undefined: foo"}, true},
+		{"pkg/a.go:3: warning: unused result (errcheck)", exec.Diagnostic{File: "pkg/a.go", Line: 3, Severity: "warning", Message: "unused result", Rule: "errcheck"}, true},
+		{"ok  \tgithub.com/pkg/exec\t0.5s", exec.Diagnostic{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := exec.ParseGNUDiagnostic(tt.line)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("%q: want %+v, %v, got %+v, %v", tt.line, tt.want, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestParseESLintDiagnostic(t *testing.T) {
+	got, ok := exec.ParseESLintDiagnostic("/src/app.js: line 4, col 10, Warning - 'x' is unused (no-unused-vars)")
+	want := exec.Diagnostic{File: "/src/app.js", Line: 4, Column: 10, Severity: "warning", Message: "'x' is unused", Rule: "no-unused-vars"}
+	if !ok || got != want {
+		t.Errorf("want %+v, got %+v, %v", want, got, ok)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	var annotations bytes.Buffer
+	var diags []exec.Diagnostic
+	annotate := exec.GitHubAnnotations(&annotations)
+	err := helperCommand(t, "pipetest").Run(
+		exec.Stdin(strings.NewReader("O:a.go:1:2: bad, really\nE:building\n")),
+		exec.Diagnostics(func(line string) (exec.Diagnostic, bool) {
+			return exec.ParseGNUDiagnostic(strings.TrimPrefix(line, "O:"))
+		}, func(d exec.Diagnostic) {
+			diags = append(diags, d)
+			annotate(d)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := annotations.String(), "::error file=a.go,line=1,col=2::bad, really\n"; got != want {
+		t.Errorf("want annotation %q, got %q", want, got)
+	}
+
+	var b bytes.Buffer
+	if err := exec.WriteSARIF(&b, "vet", diags); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string
+		Runs    []struct {
+			Results []struct {
+				Level   string
+				Message struct{ Text string }
+			}
+		}
+	}
+	if err := json.Unmarshal(b.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != 1 {
+		t.Fatalf("unexpected SARIF:\n%s", b.String())
+	}
+	if got := log.Runs[0].Results[0]; got.Level != "error" || got.Message.Text != "bad, really" {
+		t.Errorf("unexpected result %+v", got)
+	}
+}
@@ -0,0 +1,205 @@
+package exec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Requirement is an external dependency checked by Doctor. Each field
+// which is set adds a check.
+type Requirement struct {
+	// Tool is a program which must be found by LookPath.
+	Tool string
+
+	// MinVersion is the least acceptable version of Tool, as numbers
+	// separated by dots such as "2.30". It is compared with the first
+	// such version in the output of Tool run with VersionArgs, which
+	// default to "--version".
+	MinVersion  string
+	VersionArgs []string
+
+	// Env lists environment variables which must be set and not empty.
+	Env []string
+
+	// Dirs lists directories which must exist and be writable.
+	Dirs []string
+}
+
+// Check is the result of one check made by Doctor.
+type Check struct {
+	Name   string // the tool, "$" and the variable, or the directory
+	Detail string // what was found, such as the path and version of a tool
+	Err    error  // nil if the check passed
+}
+
+// DoctorReport is the result of Doctor.
+type DoctorReport struct {
+	Checks []Check
+}
+
+// OK reports whether every check passed.
+func (r *DoctorReport) OK() bool {
+	for _, c := range r.Checks {
+		if c.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Err returns nil if every check passed, the error of the failed check
+// if one failed, and a MultiError of them if several did.
+func (r *DoctorReport) Err() error {
+	var errs []error
+	for _, c := range r.Checks {
+		if c.Err != nil {
+			errs = append(errs, fmt.Errorf("exec: %s: %w", c.Name, c.Err))
+		}
+	}
+	return joinErrors(errs)
+}
+
+// WriteText writes the report to w with a line for each check, as in
+//
+//	ok    git: /usr/bin/git 2.39.2
+//	FAIL  $GITHUB_TOKEN: not set
+func (r *DoctorReport) WriteText(w io.Writer) error {
+	var b bytes.Buffer
+	for _, c := range r.Checks {
+		status, detail := "ok", c.Detail
+		if c.Err != nil {
+			status, detail = "FAIL", c.Err.Error()
+		}
+		if detail == "" {
+			fmt.Fprintf(&b, "%-5s %s\n", status, c.Name)
+		} else {
+			fmt.Fprintf(&b, "%-5s %s: %s\n", status, c.Name, detail)
+		}
+	}
+	_, err := b.WriteTo(w)
+	return err
+}
+
+// ErrVersionTooOld is the error of a Check of a tool older than its
+// Requirement's MinVersion.
+var ErrVersionTooOld = errors.New("version too old")
+
+// Doctor checks that the requirements are met, so a program can report
+// on its external dependencies with a "doctor" subcommand:
+//
+//	r := exec.Doctor([]exec.Requirement{
+//		{Tool: "git", MinVersion: "2.30"},
+//		{Tool: "docker", Env: []string{"DOCKER_HOST"}},
+//		{Dirs: []string{cacheDir}},
+//	})
+//	r.WriteText(os.Stdout)
+//	if !r.OK() {
+//		os.Exit(1)
+//	}
+//
+// The checks are reported in the order of the requirements and of their
+// fields.
+func Doctor(reqs []Requirement) *DoctorReport {
+	r := &DoctorReport{}
+	for _, req := range reqs {
+		if req.Tool != "" {
+			r.Checks = append(r.Checks, checkTool(req))
+		}
+		for _, key := range req.Env {
+			c := Check{Name: "$" + key}
+			if v, ok := os.LookupEnv(key); !ok {
+				c.Err = errors.New("not set")
+			} else if v == "" {
+				c.Err = errors.New("empty")
+			} else {
+				c.Detail = "set"
+			}
+			r.Checks = append(r.Checks, c)
+		}
+		for _, dir := range req.Dirs {
+			r.Checks = append(r.Checks, Check{Name: dir, Detail: "writable", Err: checkWritable(dir)})
+		}
+	}
+	return r
+}
+
+func checkTool(req Requirement) Check {
+	c := Check{Name: req.Tool}
+	path, err := LookPath(req.Tool)
+	if err != nil {
+		c.Err = errors.New("not found")
+		return c
+	}
+	c.Detail = path
+	if req.MinVersion == "" {
+		return c
+	}
+	args := req.VersionArgs
+	if args == nil {
+		args = []string{"--version"}
+	}
+	var b bytes.Buffer
+	err = Command(path, args...).Run(Stdout(&b), Stderr(&b), MaxOutput(1<<16), IdleTimeout(10*time.Second))
+	version := dottedVersion.FindString(b.String())
+	if version == "" {
+		if err == nil {
+			err = errors.New("no version in output")
+		}
+		c.Err = fmt.Errorf("%s %s: %w", path, strings.Join(args, " "), err)
+		return c
+	}
+	c.Detail += " " + version
+	if compareVersions(version, req.MinVersion) < 0 {
+		c.Err = fmt.Errorf("%w: %s is older than %s", ErrVersionTooOld, version, req.MinVersion)
+	}
+	return c
+}
+
+var dottedVersion = regexp.MustCompile(`\d+(?:\.\d+)+`)
+
+// compareVersions compares versions of dotted numbers, returning -1, 0
+// or 1. Missing numbers count as zero, so "2.30" and "2.30.0" are equal.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// checkWritable checks that a file can be created in dir.
+func checkWritable(dir string) error {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return errors.New("not a directory")
+	}
+	f, err := ioutil.TempFile(dir, ".doctor-")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,85 @@
+package exec
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// DrainableStdin specifies the process's standard input, like Stdin, but
+// allows Drain to stop feeding it to the child. r is copied to the child
+// through a pipe.
+func DrainableStdin(r io.Reader) func(*Cmd) error {
+	return Describe("DrainableStdin", []Param{{"r", r}}, func(c *Cmd) error {
+		if c.Stdin != nil {
+			return errors.New("exec: Stdin already set")
+		}
+		d := &stdinDrain{}
+		c.drain = d
+		c.starting = append(c.starting, func(c *Cmd) error {
+			pr, pw, err := os.Pipe()
+			if err != nil {
+				return err
+			}
+			c.Stdin, d.w = pr, pw
+			c.started = append(c.started, func(*Cmd) error {
+				pr.Close()
+				go func() {
+					io.Copy(pw, r)
+					d.close()
+				}()
+				return nil
+			})
+			c.finished = append(c.finished, func(*Cmd) error {
+				pr.Close() // if the command failed to start
+				d.close()
+				return nil
+			})
+			return nil
+		})
+		return nil
+	})
+}
+
+// Drain shuts down a command consuming a stream on stdin without
+// truncating its output. It stops feeding stdin, which must have been
+// set by DrainableStdin, and closes it, then waits up to grace for the
+// child to finish its work and exit. If it does not, it is stopped as by
+// Stop with os.Interrupt and a further grace. Drain reports whether the
+// command exited within the first grace period.
+//
+// Like Stop, Drain waits for the command to exit and returns the error
+// from Wait, so Wait must not be called on a drained command.
+func (c *Cmd) Drain(grace time.Duration) (bool, error) {
+	if c.Process == nil {
+		return false, errors.New("exec: not started")
+	}
+	if c.drain == nil {
+		return false, errors.New("exec: Drain requires DrainableStdin")
+	}
+	c.drain.close()
+	done := make(chan error, 1)
+	go func() { done <- c.Wait() }()
+	expired, stop := after(c.clk(), grace)
+	defer stop()
+	select {
+	case err := <-done:
+		return true, err
+	case <-expired:
+		_, err := c.stop(os.Interrupt, grace, done)
+		return false, err
+	}
+}
+
+// stdinDrain is the writing end of the pipe connected to the child's
+// stdin by DrainableStdin.
+type stdinDrain struct {
+	once sync.Once
+	w    *os.File
+}
+
+func (d *stdinDrain) close() {
+	d.once.Do(func() { d.w.Close() })
+}
@@ -0,0 +1,49 @@
+package exec
+
+// Capability is a Linux capability, which DropCapabilities may keep.
+// The values are those of the corresponding CAP_ constants.
+type Capability uint
+
+const (
+	CapChown             Capability = 0
+	CapDacOverride       Capability = 1
+	CapDacReadSearch     Capability = 2
+	CapFowner            Capability = 3
+	CapFsetid            Capability = 4
+	CapKill              Capability = 5
+	CapSetgid            Capability = 6
+	CapSetuid            Capability = 7
+	CapSetpcap           Capability = 8
+	CapLinuxImmutable    Capability = 9
+	CapNetBindService    Capability = 10
+	CapNetBroadcast      Capability = 11
+	CapNetAdmin          Capability = 12
+	CapNetRaw            Capability = 13
+	CapIpcLock           Capability = 14
+	CapIpcOwner          Capability = 15
+	CapSysModule         Capability = 16
+	CapSysRawio          Capability = 17
+	CapSysChroot         Capability = 18
+	CapSysPtrace         Capability = 19
+	CapSysPacct          Capability = 20
+	CapSysAdmin          Capability = 21
+	CapSysBoot           Capability = 22
+	CapSysNice           Capability = 23
+	CapSysResource       Capability = 24
+	CapSysTime           Capability = 25
+	CapSysTtyConfig      Capability = 26
+	CapMknod             Capability = 27
+	CapLease             Capability = 28
+	CapAuditWrite        Capability = 29
+	CapAuditControl      Capability = 30
+	CapSetfcap           Capability = 31
+	CapMacOverride       Capability = 32
+	CapMacAdmin          Capability = 33
+	CapSyslog            Capability = 34
+	CapWakeAlarm         Capability = 35
+	CapBlockSuspend      Capability = 36
+	CapAuditRead         Capability = 37
+	CapPerfmon           Capability = 38
+	CapBpf               Capability = 39
+	CapCheckpointRestore Capability = 40
+)
@@ -0,0 +1,101 @@
+package exec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// capsEnv is the environment variable in which DropCapabilities passes
+// the capabilities to keep to the sandbox shim.
+const capsEnv = "EXEC_KEEP_CAPABILITIES"
+
+// DropCapabilities runs the child with only the capabilities keep, of
+// those the caller has, so a privileged parent may run a child with the
+// least privilege it needs. The other capabilities are dropped from the
+// child's bounding set, so that neither it nor its descendants may
+// regain them, even by executing setuid or file capability programs,
+// and those kept are raised in its ambient set, so that they survive
+// executing the program even if the child does not run as root.
+//
+// Capabilities are dropped by the sandbox shim, as with Seccomp, so the
+// main function must begin by calling Reexec. Dropping capabilities
+// from the bounding set requires CAP_SETPCAP.
+//
+//	cmd.Run(exec.DropCapabilities(exec.CapNetBindService))
+func DropCapabilities(keep ...Capability) func(*Cmd) error {
+	return Describe("DropCapabilities", []Param{{"keep", keep}}, func(c *Cmd) error {
+		s := make([]string, len(keep))
+		for i, cap := range keep {
+			s[i] = strconv.FormatUint(uint64(cap), 10)
+		}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			return applyOptions(c, Setenv(capsEnv, strings.Join(s, ",")))
+		})
+		return c.sandbox()
+	})
+}
+
+const (
+	prCapbsetDrop          = 24
+	prCapAmbient           = 47
+	prCapAmbientRaise      = 2
+	linuxCapabilityVersion = 0x20080522 // _LINUX_CAPABILITY_VERSION_3
+)
+
+type capHeader struct {
+	version uint32
+	pid     int32
+}
+
+type capData struct {
+	effective, permitted, inheritable uint32
+}
+
+// dropCapabilities restricts the current thread to the capabilities
+// encoded in keep by DropCapabilities.
+func dropCapabilities(encoded string) error {
+	var keep [2]uint32
+	if encoded != "" {
+		for _, s := range strings.Split(encoded, ",") {
+			n, err := strconv.ParseUint(s, 10, 6)
+			if err != nil {
+				return err
+			}
+			keep[n/32] |= 1 << (n % 32)
+		}
+	}
+	for n := uintptr(0); n < 64; n++ {
+		if keep[n/32]&(1<<(n%32)) != 0 {
+			continue
+		}
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prCapbsetDrop, n, 0); errno == syscall.EINVAL {
+			break // beyond the last capability the kernel knows
+		} else if errno != 0 {
+			return fmt.Errorf("dropping %d from the bounding set: %v", n, errno)
+		}
+	}
+	hdr := capHeader{version: linuxCapabilityVersion}
+	var data [2]capData
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_CAPGET, uintptr(unsafe.Pointer(&hdr)), uintptr(unsafe.Pointer(&data[0])), 0); errno != 0 {
+		return fmt.Errorf("capget: %v", errno)
+	}
+	for i := range data {
+		keep[i] &= data[i].permitted
+		data[i] = capData{effective: keep[i], permitted: keep[i], inheritable: keep[i]}
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_CAPSET, uintptr(unsafe.Pointer(&hdr)), uintptr(unsafe.Pointer(&data[0])), 0); errno != 0 {
+		return fmt.Errorf("capset: %v", errno)
+	}
+	for n := uintptr(0); n < 64; n++ {
+		if keep[n/32]&(1<<(n%32)) == 0 {
+			continue
+		}
+		if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prCapAmbient, prCapAmbientRaise, n, 0, 0, 0); errno != 0 {
+			return fmt.Errorf("raising %d in the ambient set: %v", n, errno)
+		}
+	}
+	return nil
+}
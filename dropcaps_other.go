@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package exec
+
+// DropCapabilities runs the child with only the Linux capabilities
+// keep. It is only supported on Linux.
+func DropCapabilities(keep ...Capability) func(*Cmd) error {
+	return Describe("DropCapabilities", []Param{{"keep", keep}}, func(*Cmd) error {
+		return notSupported("DropCapabilities")
+	})
+}
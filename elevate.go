@@ -0,0 +1,64 @@
+package exec
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Elevator is the command line prefix of a program which runs a command
+// with administrator rights, see Elevated.
+type Elevator []string
+
+// Elevators for common tools. Sudo and Doas fail rather than prompt for
+// a password; SudoAskpass asks the program named by SUDO_ASKPASS, which
+// Askpass sets, so the password may be supplied by the caller.
+var (
+	Sudo        = Elevator{"sudo", "-n", "--"}
+	SudoAskpass = Elevator{"sudo", "-A", "--"}
+	Doas        = Elevator{"doas", "-n", "--"}
+	Pkexec      = Elevator{"pkexec"}
+)
+
+// Elevated runs the command with administrator rights by way of e, for
+// example Sudo. If the parent is already running as root the command is
+// run directly. It is not supported on Windows, where elevation requires
+// a UAC prompt and the child's stdio cannot be connected.
+//
+// As sudo, doas and pkexec reset the environment, the variables set by
+// options such as Setenv, EnvFile and Correlate are passed by running
+// the program with env; others set in Env are not passed. Pkexec runs
+// the program in root's home directory, so it cannot be used with Dir.
+//
+//	cmd.Run(exec.Elevated(exec.SudoAskpass), exec.Askpass(promptForPassword))
+func Elevated(e Elevator) func(*Cmd) error {
+	return Describe("Elevated", []Param{{"e", e}}, func(c *Cmd) error {
+		if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+			return notSupported("Elevated")
+		}
+		if len(e) == 0 {
+			return errors.New("exec: Elevated requires an Elevator")
+		}
+		if os.Geteuid() == 0 {
+			return nil
+		}
+		path, err := LookPath(e[0])
+		if err != nil {
+			return err
+		}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			if c.Dir != "" && filepath.Base(e[0]) == "pkexec" {
+				return errors.New("exec: Elevated by pkexec cannot be used with Dir")
+			}
+			args := append([]string(nil), e...)
+			if env, _ := c.optionEnv(); len(env) > 0 {
+				args = append(append(args, "env"), env...)
+			}
+			// pass the resolved program, as sudo may search a different PATH.
+			c.Path, c.Args = path, append(append(args, c.Path), c.Args[1:]...)
+			return nil
+		})
+		return nil
+	})
+}
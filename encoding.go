@@ -0,0 +1,222 @@
+package exec
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Decoder converts text in some character encoding to UTF-8. Its methods
+// match those of transform.Transformer, so the *encoding.Decoder of any
+// golang.org/x/text/encoding.Encoding, for example that returned by
+// japanese.ShiftJIS.NewDecoder, is a Decoder.
+type Decoder interface {
+	Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error)
+	Reset()
+}
+
+// OutputEncoding converts the output of the child from the encoding
+// decoded by the Decoders returned by newDecoder to UTF-8, before it
+// reaches the writers connected to stdout and stderr. A Decoder is used
+// for each stream, or one if stdout and stderr share a writer.
+//
+//	cmd.Run(exec.OutputEncoding(exec.UTF16LE))
+//	cmd.Run(exec.OutputEncoding(func() exec.Decoder {
+//		return charmap.Windows1252.NewDecoder()
+//	}))
+func OutputEncoding(newDecoder func() Decoder) func(*Cmd) error {
+	return Describe("OutputEncoding", []Param{{"newDecoder", newDecoder}}, func(c *Cmd) error {
+		var dws []*decodeWriter
+		c.starting = append(c.starting, func(c *Cmd) error {
+			wrapOutput(c, func(w io.Writer) io.Writer {
+				dw := &decodeWriter{w: w, d: newDecoder()}
+				dws = append(dws, dw)
+				return dw
+			})
+			return nil
+		})
+		c.finished = append(c.finished, func(*Cmd) error {
+			var err error
+			for _, dw := range dws {
+				if errFlush := dw.flush(); err == nil {
+					err = errFlush
+				}
+			}
+			return err
+		})
+		return nil
+	})
+}
+
+// maxPending is the most input a decodeWriter will hold back waiting for
+// the rest of an encoded character before reporting the decoder's error.
+const maxPending = 64
+
+// decodeWriter passes the result of decoding the bytes written to it
+// to w.
+type decodeWriter struct {
+	w io.Writer
+	d Decoder
+
+	mu      sync.Mutex
+	pending []byte // input not yet consumed by d
+	buf     []byte
+}
+
+func (dw *decodeWriter) Write(p []byte) (int, error) {
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+	dw.pending = append(dw.pending, p...)
+	if err := dw.transform(false); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (dw *decodeWriter) transform(atEOF bool) error {
+	for len(dw.pending) > 0 {
+		// UTF-8 never needs more than three bytes for a character which
+		// takes one byte in any other encoding, so dst is never short.
+		if n := 3*len(dw.pending) + utf8.UTFMax; len(dw.buf) < n {
+			dw.buf = make([]byte, n)
+		}
+		nDst, nSrc, err := dw.d.Transform(dw.buf, dw.pending, atEOF)
+		if nDst > 0 {
+			if _, err := dw.w.Write(dw.buf[:nDst]); err != nil {
+				return err
+			}
+		}
+		dw.pending = dw.pending[:copy(dw.pending, dw.pending[nSrc:])]
+		switch {
+		case err == nil && nSrc > 0:
+			continue
+		case err == nil:
+			return nil
+		case atEOF || nSrc == 0 && len(dw.pending) >= maxPending:
+			return err
+		}
+		// the remaining input is an incomplete character.
+		return nil
+	}
+	return nil
+}
+
+// flush decodes any input held back by the decoder.
+func (dw *decodeWriter) flush() error {
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+	return dw.transform(true)
+}
+
+// UTF16LE returns a Decoder for little endian UTF-16, as written by many
+// Windows tools. A byte order mark, if present, is removed and overrides
+// the byte order.
+func UTF16LE() Decoder { return &utf16Decoder{} }
+
+// UTF16BE returns a Decoder for big endian UTF-16, see UTF16LE.
+func UTF16BE() Decoder { return &utf16Decoder{bigEndian: true, defaultBE: true} }
+
+type utf16Decoder struct {
+	bigEndian bool
+	defaultBE bool // restored by Reset
+	started   bool // the byte order mark has been checked for
+}
+
+func (d *utf16Decoder) Reset() {
+	d.started = false
+	d.bigEndian = d.defaultBE
+}
+
+func (d *utf16Decoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	if !d.started {
+		if len(src) < 2 && !atEOF {
+			return 0, 0, errShortSrc
+		}
+		if len(src) >= 2 {
+			d.started = true
+			switch {
+			case src[0] == 0xff && src[1] == 0xfe:
+				d.bigEndian, nSrc = false, 2
+			case src[0] == 0xfe && src[1] == 0xff:
+				d.bigEndian, nSrc = true, 2
+			}
+		}
+	}
+	unit := func(i int) uint16 {
+		if d.bigEndian {
+			return uint16(src[i])<<8 | uint16(src[i+1])
+		}
+		return uint16(src[i+1])<<8 | uint16(src[i])
+	}
+	for nSrc+1 < len(src) {
+		r, size := rune(unit(nSrc)), 2
+		if utf16.IsSurrogate(r) {
+			if nSrc+3 >= len(src) {
+				if !atEOF {
+					return nDst, nSrc, errShortSrc
+				}
+				r = utf8.RuneError
+			} else {
+				r, size = utf16.DecodeRune(r, rune(unit(nSrc+2))), 4
+				if r == utf8.RuneError {
+					size = 2
+				}
+			}
+		}
+		if len(dst)-nDst < utf8.RuneLen(r) {
+			return nDst, nSrc, errShortDst
+		}
+		nDst += utf8.EncodeRune(dst[nDst:], r)
+		nSrc += size
+	}
+	if nSrc < len(src) {
+		if !atEOF {
+			return nDst, nSrc, errShortSrc
+		}
+		// a trailing odd byte.
+		if len(dst)-nDst < 3 {
+			return nDst, nSrc, errShortDst
+		}
+		nDst += utf8.EncodeRune(dst[nDst:], utf8.RuneError)
+		nSrc++
+	}
+	return nDst, nSrc, nil
+}
+
+// Windows1252 returns a Decoder for the Windows-1252 code page, the
+// default for console output on western European Windows systems.
+// It is a superset of ISO-8859-1.
+func Windows1252() Decoder { return windows1252{} }
+
+// windows1252High maps bytes 0x80 to 0x9f, which differ from ISO-8859-1.
+// The five unassigned bytes map to the corresponding C1 controls.
+var windows1252High = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '\u008d', 'Ž', '\u008f',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '\u009d', 'ž', 'Ÿ',
+}
+
+type windows1252 struct{}
+
+func (windows1252) Reset() {}
+
+func (windows1252) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for _, b := range src {
+		r := rune(b)
+		if b >= 0x80 && b < 0xa0 {
+			r = windows1252High[b-0x80]
+		}
+		if len(dst)-nDst < utf8.RuneLen(r) {
+			return nDst, nSrc, errShortDst
+		}
+		nDst += utf8.EncodeRune(dst[nDst:], r)
+		nSrc++
+	}
+	return nDst, nSrc, nil
+}
+
+var (
+	errShortDst = errors.New("exec: short destination buffer")
+	errShortSrc = errors.New("exec: short source buffer")
+)
@@ -0,0 +1,36 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvFile sets the variables in the .env file at path in the child's
+// environment, in the format read by ParseEnv, as Setenv would in turn,
+// so they override those set by earlier options and are overridden by
+// later ones. References to variables in values which are not single
+// quoted, as $KEY or ${KEY}, are expanded to those set earlier in the
+// file, or else in the child's environment, and otherwise to "".
+//
+//	cmd.Run(exec.EnvFile(".env"), exec.Setenv("DEBUG", "1"))
+func EnvFile(path string) func(*Cmd) error {
+	return Describe("EnvFile", []Param{{"path", path}}, func(c *Cmd) error {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		env, err := parseEnv(f, c.getenv)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, kv := range env {
+			key, val, _ := strings.Cut(kv, "=")
+			if err := applyOptions(c, Setenv(key, val)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
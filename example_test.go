@@ -86,3 +86,15 @@ func ExampleCmd_Start_Setenv() {
 		log.Fatal(err)
 	}
 }
+
+func ExampleCapabilities() {
+	// only limit the child's bandwidth where the host supports it.
+	cmd := exec.Command("rsync", "-a", "src/", "host:dst/")
+	var opts []func(*exec.Cmd) error
+	if exec.Capabilities().NetCls {
+		opts = append(opts, exec.NetRateLimit(10<<20))
+	}
+	if err := cmd.Run(opts...); err != nil {
+		log.Fatal(err)
+	}
+}
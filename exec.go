@@ -7,7 +7,11 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
 )
 
 // System executes the command specified in command by calling /bin/sh -c command, and returns after the command has been completed. Stdin, Stdout, and Stderr are plumbed through to the child, but this behaviour can be modified by opts.
@@ -45,6 +49,41 @@ type Cmd struct {
 	initalised    bool
 	waited        bool
 	before, after func(*Cmd) error
+
+	// starting, started and finished are registered by options which
+	// need to act just before the process is started, on the running
+	// process, or clean up once it has exited.
+	starting, started, finished []func(*Cmd) error
+
+	opts     []func(*Cmd) error // applied before those passed to Start
+	resolved []func(*Cmd) error // applied once the program is resolved; see Resolved
+	warn     func(*Cmd, error)
+	tail     [2]*lineRing // stdout, stderr; see TailLines
+	monitor  *resourceMonitor
+	runID    string // see Correlate
+	drain    *stdinDrain
+	clock    Clock // see UseClock
+
+	redactEnv      []string                 // see RedactEnv
+	redactPatterns []*regexp.Regexp         // see RedactArgs
+	sandboxed      bool                     // run by the sandbox shim, see Landlock
+	spawn          func(func() error) error // calls Start elsewhere, see KillOnParentDeath
+	backend        Backend                  // see BackendCommand
+	envKeys        []string                 // set or removed by Setenv and Unsetenv, see optionEnv
+	upperDir       string                   // see OverlayRoot
+	tempDir        string                   // see TempDir
+	keepOnFailure  bool                     // see KeepOnFailure
+
+	// describing is set while Spec.MarshalJSON records the options of a
+	// spec without applying them.
+	describing bool
+
+	startTime, exitTime time.Time // see Usage
+
+	mu       sync.Mutex
+	killed   error // reason the process was killed, returned by Wait
+	warnings []error
+	applied  []AppliedOption // see AppliedOptions
 }
 
 // Run starts the specified command and waits for it to complete.
@@ -71,18 +110,110 @@ func (c *Cmd) Start(opts ...func(*Cmd) error) error {
 	if !c.initalised {
 		return errors.New("exec: command not initalised")
 	}
+	if err := c.start(opts...); err != nil {
+		// release anything acquired by options which were applied.
+		c.runFinished()
+		return err
+	}
+	return nil
+}
+
+func (c *Cmd) start(opts ...func(*Cmd) error) error {
+	if err := c.configure(opts...); err != nil {
+		return err
+	}
+	c.startTime = c.clk().Now()
+	spawn := func(start func() error) error { return start() }
+	if c.spawn != nil {
+		spawn = c.spawn
+	}
+	if err := startChild(c, func() error { return spawn(c.Cmd.Start) }); err != nil {
+		return err
+	}
+	for _, fn := range c.started {
+		if err := callHook(c, fn); err != nil {
+			c.Process.Kill()
+			waitChild(c)
+			c.exitTime = c.clk().Now()
+			return err
+		}
+	}
+	return nil
+}
+
+// configure applies the command's options, in order, and runs its
+// before and starting hooks.
+func (c *Cmd) configure(opts ...func(*Cmd) error) error {
 	if err := applyDefaultOptions(c); err != nil {
 		return err
 	}
+	if err := applyOptions(c, registeredDefaults(c.Path)...); err != nil {
+		return err
+	}
+	if err := applyOptions(c, c.opts...); err != nil {
+		return err
+	}
 	if err := applyOptions(c, opts...); err != nil {
 		return err
 	}
+	if c.backend != nil {
+		if err := c.checkBackendOptions(); err != nil {
+			return err
+		}
+	}
+	if len(c.resolved) > 0 {
+		if err := c.resolve(); err != nil {
+			return err
+		}
+		// options applied here may themselves use Resolved.
+		for len(c.resolved) > 0 {
+			opt := c.resolved[0]
+			c.resolved = c.resolved[1:]
+			if err := callHook(c, opt); err != nil {
+				return err
+			}
+		}
+	}
 	if c.before != nil {
-		if err := c.before(c); err != nil {
+		if err := callHook(c, c.before); err != nil {
+			return err
+		}
+	}
+	for _, fn := range c.starting {
+		if err := callHook(c, fn); err != nil {
 			return err
 		}
 	}
-	return c.Cmd.Start()
+	if c.backend != nil {
+		if err := c.wrapBackend(); err != nil {
+			return err
+		}
+	}
+	if err := c.checkPolicy(); err != nil {
+		return err
+	}
+	// a callback may have panicked.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.killed
+}
+
+// resolve makes the path of the program absolute, returning the error
+// from looking it up in PATH, if any. The program of a BackendCommand is
+// left as named.
+func (c *Cmd) resolve() error {
+	if c.Err != nil {
+		return c.Err
+	}
+	if filepath.IsAbs(c.Path) || c.backend != nil {
+		return nil
+	}
+	path, err := filepath.Abs(filepath.Join(c.Dir, c.Path))
+	if err != nil {
+		return err
+	}
+	c.Path = path
+	return nil
 }
 
 // Wait waits for the command to exit.
@@ -96,84 +227,203 @@ func (c *Cmd) Wait() (err error) {
 		if c.after == nil {
 			return
 		}
-		errAfter := c.after(c)
+		errAfter := callHook(c, c.after)
 		if err == nil {
 			err = errAfter
 		}
 	}()
-	return c.Cmd.Wait()
+	err = waitChild(c)
+	c.exitTime = c.clk().Now()
+	c.mu.Lock()
+	if c.killed != nil {
+		err = c.killed
+	}
+	c.mu.Unlock()
+	if errFinished := c.runFinished(); err == nil {
+		err = errFinished
+	}
+	return err
+}
+
+// kill kills the running process, recording err as the reason
+// to be returned from Wait, or from Start if it has not yet started.
+func (c *Cmd) kill(err error) {
+	c.mu.Lock()
+	if c.killed == nil {
+		c.killed = err
+	}
+	c.mu.Unlock()
+	if c.Process != nil {
+		c.Process.Kill()
+	}
+}
+
+// runFinished runs the finished hooks registered by options, returning
+// the first error encountered.
+func (c *Cmd) runFinished() error {
+	var err error
+	for _, fn := range c.finished {
+		if errFn := callHook(c, fn); err == nil {
+			err = errFn
+		}
+	}
+	c.finished = nil
+	return err
+}
+
+// Warnings returns the non fatal problems encountered while configuring
+// or running the command, such as options skipped by BestEffort or
+// output discarded by MaxOutput.
+func (c *Cmd) Warnings() []error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]error(nil), c.warnings...)
+}
+
+// addWarning records a non fatal problem, passing it to the WarningFunc
+// if one is set.
+func (c *Cmd) addWarning(err error) {
+	c.mu.Lock()
+	c.warnings = append(c.warnings, err)
+	fn := c.warn
+	c.mu.Unlock()
+	if fn != nil {
+		c.guard(func() { fn(c, err) })
+	}
 }
 
 // Stdin specifies the process's standard input.
 func Stdin(r io.Reader) func(*Cmd) error {
-	return func(c *Cmd) error {
+	return Describe("Stdin", []Param{{"r", r}}, func(c *Cmd) error {
 		if c.Stdin != nil {
 			return errors.New("exec: Stdin already set")
 		}
 		c.Stdin = r
 		return nil
-	}
+	})
+}
+
+// StdinGenerator specifies the process's standard input as the reader
+// returned by fn, which is called each time a command is started rather
+// than when the option is created. A Spec using it reads fresh input for
+// every Cmd, for example a re-opened file. If the reader is an io.Closer,
+// it is closed once the command has exited.
+func StdinGenerator(fn func() (io.Reader, error)) func(*Cmd) error {
+	return Describe("StdinGenerator", []Param{{"fn", fn}}, func(c *Cmd) error {
+		if c.Stdin != nil {
+			return errors.New("exec: Stdin already set")
+		}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			r, err := fn()
+			if err != nil {
+				return err
+			}
+			c.Stdin = r
+			if rc, ok := r.(io.Closer); ok {
+				c.finished = append(c.finished, func(*Cmd) error {
+					return rc.Close()
+				})
+			}
+			return nil
+		})
+		return nil
+	})
 }
 
 // Stdout specifies the process's standard output.
 func Stdout(w io.Writer) func(*Cmd) error {
-	return func(c *Cmd) error {
+	return Describe("Stdout", []Param{{"w", w}}, func(c *Cmd) error {
 		if c.Stdout != nil {
 			return errors.New("exec: Stdout already set")
 		}
 		c.Stdout = w
 		return nil
-	}
+	})
 }
 
 // Stderr specifies the process's standard error..
 func Stderr(w io.Writer) func(*Cmd) error {
-	return func(c *Cmd) error {
+	return Describe("Stderr", []Param{{"w", w}}, func(c *Cmd) error {
 		if c.Stderr != nil {
 			return errors.New("exec: Stderr already set")
 		}
 		c.Stderr = w
 		return nil
-	}
+	})
 }
 
 // BeforeFunc runs fn just prior to executing the command. If an error
 // is returned, the command will not be run.
 func BeforeFunc(fn func(*Cmd) error) func(*Cmd) error {
-	return func(c *Cmd) error {
+	return Describe("BeforeFunc", []Param{{"fn", fn}}, func(c *Cmd) error {
 		if c.before != nil {
 			return errors.New("exec: BeforeFunc already set")
 		}
 		c.before = fn
 		return nil
-	}
+	})
 }
 
 // AfterFunc runs fn just after to executing the command. If an error
 // is returned, it will be returned providing the command exited cleanly.
 func AfterFunc(fn func(*Cmd) error) func(*Cmd) error {
-	return func(c *Cmd) error {
+	return Describe("AfterFunc", []Param{{"fn", fn}}, func(c *Cmd) error {
 		if c.after != nil {
 			return errors.New("exec: AfterFunc already set")
 		}
 		c.after = fn
 		return nil
-	}
+	})
+}
+
+// WarningFunc calls fn as each warning is recorded for the command,
+// in addition to it being available from Warnings. Warnings recorded
+// by earlier options are passed to fn immediately. fn may be called
+// from another goroutine while the command is running.
+func WarningFunc(fn func(*Cmd, error)) func(*Cmd) error {
+	return Describe("WarningFunc", []Param{{"fn", fn}}, func(c *Cmd) error {
+		if c.warn != nil {
+			return errors.New("exec: WarningFunc already set")
+		}
+		c.warn = fn
+		for _, err := range c.Warnings() {
+			err := err
+			c.guard(func() { fn(c, err) })
+		}
+		return nil
+	})
 }
 
 // Setenv applies (or overwrites) childs environment key.
 func Setenv(key, val string) func(*Cmd) error {
-	return func(c *Cmd) error {
-		key += "="
+	return Describe("Setenv", []Param{{"key", key}, {"val", val}}, func(c *Cmd) error {
+		c.envKeys = append(c.envKeys, key)
+		prefix := key + "="
 		for i := range c.Env {
-			if strings.HasPrefix(c.Env[i], key) {
-				c.Env[i] = key + val
+			if strings.HasPrefix(c.Env[i], prefix) {
+				c.Env[i] = prefix + val
 				return nil
 			}
 		}
-		c.Env = append(c.Env, key+val)
+		c.Env = append(c.Env, prefix+val)
 		return nil
-	}
+	})
+}
+
+// Unsetenv removes key from the child's environment.
+func Unsetenv(key string) func(*Cmd) error {
+	return Describe("Unsetenv", []Param{{"key", key}}, func(c *Cmd) error {
+		c.envKeys = append(c.envKeys, key)
+		prefix := key + "="
+		env := c.Env[:0]
+		for _, kv := range c.Env {
+			if !strings.HasPrefix(kv, prefix) {
+				env = append(env, kv)
+			}
+		}
+		c.Env = env
+		return nil
+	})
 }
 
 // Output runs the command and returns its standard output.
@@ -184,14 +434,57 @@ func (c *Cmd) Output(opts ...func(*Cmd) error) ([]byte, error) {
 	return b.Bytes(), err
 }
 
+// Resolved applies opts in a second phase, after all other options have
+// been applied and the program has been resolved to an absolute path,
+// and before any BeforeFunc. Options applied in this phase see the
+// final configuration of the command, and may inspect the program to
+// adapt its arguments.
+//
+//	exec.Resolved(func(c *exec.Cmd) error {
+//		if ok, _ := exec.Supports(c.Path, "--color"); ok {
+//			c.Args = append(c.Args, "--color=never")
+//		}
+//		return nil
+//	})
+func Resolved(opts ...func(*Cmd) error) func(*Cmd) error {
+	return Describe("Resolved", []Param{{"opts", opts}}, func(c *Cmd) error {
+		c.resolved = append(c.resolved, opts...)
+		return nil
+	})
+}
+
+// Argv0 sets the first element of the argument list the child sees to
+// name, independently of the program run, for programs such as busybox
+// which behave according to the name they are called by, or a login
+// shell invoked as "-bash".
+func Argv0(name string) func(*Cmd) error {
+	return Describe("Argv0", []Param{{"name", name}}, func(c *Cmd) error {
+		c.Args[0] = name
+		return nil
+	})
+}
+
 // Dir specifies the working directory of the command.
 // If Dir is empty, the command executes in the calling
 // process's current directory.
 func Dir(dir string) func(*Cmd) error {
-	return func(c *Cmd) error {
+	return Describe("Dir", []Param{{"dir", dir}}, func(c *Cmd) error {
 		c.Dir = dir
 		return nil
-	}
+	})
+}
+
+// DirCreate specifies the working directory of the command, as Dir
+// does, creating it and any missing parents with permissions perm
+// (before umask) just before the command starts if it does not exist.
+func DirCreate(dir string, perm os.FileMode) func(*Cmd) error {
+	return Describe("DirCreate", []Param{{"dir", dir}, {"perm", perm}}, func(c *Cmd) error {
+		c.Dir = dir
+		c.starting = append(c.starting, func(*Cmd) error {
+			return os.MkdirAll(dir, perm)
+		})
+		return nil
+	})
 }
 
 func applyDefaultOptions(c *Cmd) error {
@@ -203,7 +496,7 @@ func applyDefaultOptions(c *Cmd) error {
 
 func applyOptions(c *Cmd, opts ...func(*Cmd) error) error {
 	for _, opt := range opts {
-		if err := opt(c); err != nil {
+		if err := callHook(c, opt); err != nil {
 			return err
 		}
 	}
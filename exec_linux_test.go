@@ -0,0 +1,519 @@
+package exec_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pkg/exec"
+)
+
+func TestDiskQuota(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("skipping test; DiskQuota requires root")
+	}
+	if err := helperCommand(t, "writetmp", "4096").Run(exec.DiskQuota(1 << 20)); err != nil {
+		t.Fatalf("write within quota: %v", err)
+	}
+	if err := helperCommand(t, "writetmp", "2097152").Run(exec.DiskQuota(1 << 20)); err == nil {
+		t.Fatal("write exceeding quota: expected error")
+	}
+}
+
+func TestChrootMissingProgram(t *testing.T) {
+	err := helperCommand(t, "echo").Run(exec.Chroot(t.TempDir()))
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("want program not found in root, got %v", err)
+	}
+}
+
+func TestNewSession(t *testing.T) {
+	cmd := helperCommand(t, "sleep", "10s")
+	if err := cmd.Start(exec.NewSession()); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+	pgid, err := syscall.Getpgid(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pgid != cmd.Process.Pid {
+		t.Errorf("want child to lead its process group, got pgid %d for pid %d", pgid, cmd.Process.Pid)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	out, err := exec.Command("/bin/sh", "-c", "ulimit -Sn; ulimit -Hn").Output(exec.Limit(exec.LimitNoFile, 64, 128))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "64\n128\n"; string(out) != want {
+		t.Errorf("want %q, got %q", want, out)
+	}
+	out, err = exec.Command("/bin/sh", "-c", "echo $0; ulimit -Sn; ulimit -Sc").Output(exec.Argv0("mysh"), exec.Limit(exec.LimitNoFile, 64, 128), exec.Limit(exec.LimitCore, 0, exec.Unlimited))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "mysh\n64\n0\n"; string(out) != want {
+		t.Errorf("Argv0: want %q, got %q", want, out)
+	}
+}
+
+func TestCgroup(t *testing.T) {
+	if os.Getuid() != 0 || !exec.Capabilities().Cgroups {
+		t.Skip("skipping test; Cgroup requires root and cgroup v2")
+	}
+	// a process left behind in the group is killed before it is removed.
+	out, err := exec.Command("/bin/sh", "-c", "sleep 60 >/dev/null 2>&1 & grep ^0:: /proc/self/cgroup").Output(exec.Cgroup(exec.CgroupConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := strings.TrimSpace(strings.TrimPrefix(string(out), "0::"))
+	for _, root := range []string{"/sys/fs/cgroup", "/sys/fs/cgroup/unified"} {
+		if _, err := os.Stat(filepath.Join(root, group)); err == nil {
+			t.Errorf("want group %s removed", group)
+		}
+	}
+
+	// the shell is limited to a single process, so cannot fork.
+	out, err = exec.Command("/bin/sh", "-c", "grep -q pkg-exec- /proc/self/cgroup && echo forked").Output(exec.Cgroup(exec.CgroupConfig{PidsMax: 1}))
+	if errors.Is(err, exec.ErrNotSupported) {
+		t.Skip(err)
+	}
+	if err == nil || len(out) > 0 {
+		t.Errorf("want fork to fail within the group, got %q, %v", out, err)
+	}
+	out, err = exec.Command("/bin/sh", "-c", "cat /proc/self/cgroup").Output(exec.Cgroup(exec.CgroupConfig{PidsMax: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "pkg-exec-") {
+		t.Errorf("want child in a new group, got %q", out)
+	}
+}
+
+func TestNetRateLimit(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("skipping test; NetRateLimit requires root")
+	}
+	if _, err := exec.LookPath("tc"); err != nil {
+		t.Skip("skipping test; tc not found")
+	}
+	dev := defaultRouteDevice(t)
+	tc := func(args ...string) string {
+		out, err := exec.Command("tc", args...).Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(out)
+	}
+	if strings.Contains(tc("qdisc", "show", "dev", dev), "htb 1: root") {
+		t.Skipf("skipping test; %s already has an htb root qdisc", dev)
+	}
+	script := "grep net_cls /proc/self/cgroup; tc qdisc show dev $1; tc filter show dev $1; tc class show dev $1"
+	out, err := exec.Command("/bin/sh", "-c", script, "sh", dev).Output(exec.NetRateLimit(1 << 20))
+	if errors.Is(err, exec.ErrNotSupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"pkg-exec-", "qdisc htb 1: root", "filter parent 1: protocol all", "cgroup", "class htb 1:"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("want %q while running, got\n%s", want, out)
+		}
+	}
+	if out := tc("qdisc", "show", "dev", dev); strings.Contains(out, "htb 1: root") {
+		t.Errorf("want qdisc removed, got\n%s", out)
+	}
+
+	// processes sharing the qdisc allocate distinct classes; the qdisc
+	// is left to the process which installed it, so is removed here.
+	defer exec.Command("tc", "qdisc", "del", "dev", dev, "root", "handle", "1:", "htb").Run()
+	var cmds []*exec.Cmd
+	for i := 0; i < 2; i++ {
+		cmd := helperCommand(t, "netratelimit")
+		if err := cmd.Start(exec.Setenv("PATH", os.Getenv("PATH")), exec.Stderr(os.Stderr)); err != nil {
+			t.Fatal(err)
+		}
+		cmds = append(cmds, cmd)
+	}
+	for i, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Errorf("process %d: %v", i, err)
+		}
+	}
+}
+
+// defaultRouteDevice returns the interface carrying the IPv4 default
+// route, skipping the test if there is none.
+func defaultRouteDevice(t *testing.T) string {
+	b, err := ioutil.ReadFile("/proc/net/route")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(b), "\n")[1:] {
+		if fields := strings.Fields(line); len(fields) > 1 && fields[1] == "00000000" {
+			return fields[0]
+		}
+	}
+	t.Skip("skipping test; no default route")
+	return ""
+}
+
+func TestNice(t *testing.T) {
+	cmd := helperCommand(t, "sleep", "10s")
+	if err := cmd.Start(exec.Nice(10)); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+	// the raw syscall returns 20 - nice.
+	prio, err := syscall.Getpriority(syscall.PRIO_PROCESS, cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nice := 20 - prio; nice != 10 {
+		t.Errorf("want nice 10, got %d", nice)
+	}
+	// best-effort class, level (10 + 20) / 5.
+	ioprio, _, errno := syscall.RawSyscall(syscall.SYS_IOPRIO_GET, 1, uintptr(cmd.Process.Pid), 0)
+	if errno != 0 {
+		t.Fatal(errno)
+	}
+	if want := uintptr(2<<13 | 6); ioprio != want {
+		t.Errorf("want I/O priority %#x, got %#x", want, ioprio)
+	}
+}
+
+func TestInheritTTY(t *testing.T) {
+	tty, ttyErr := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if ttyErr == nil {
+		tty.Close()
+	}
+	var b strings.Builder
+	err := helperCommand(t, "echo", "hello").Run(exec.Stdout(&b), exec.InheritTTY())
+	switch {
+	case ttyErr != nil && err == nil:
+		t.Fatal("want error without a controlling terminal")
+	case ttyErr == nil && err != nil:
+		t.Fatal(err)
+	case ttyErr == nil && b.String() != "hello\n":
+		t.Errorf("want stdout captured, got %q", b.String())
+	}
+	if ttyErr == nil {
+		err := helperCommand(t, "echo").Run(exec.InheritTTY(), exec.NewSession())
+		if err == nil || !strings.Contains(err.Error(), "cannot be used with NewSession") {
+			t.Errorf("want NewSession rejected, got %v", err)
+		}
+	}
+}
+
+func TestOOMScoreAdj(t *testing.T) {
+	cmd := helperCommand(t, "sleep", "10s")
+	if err := cmd.Start(exec.OOMScoreAdj(500)); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+	b, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/oom_score_adj", cmd.Process.Pid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(b)); got != "500" {
+		t.Errorf("want oom_score_adj 500, got %s", got)
+	}
+}
+
+func TestAskpass(t *testing.T) {
+	var prompts []string
+	askpass := exec.Askpass(func(prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		if prompt == "Password: " {
+			return "secret", nil
+		}
+		return "", errors.New("unexpected prompt")
+	})
+	cmd := exec.Command("/bin/sh", "-c", `"$SSH_ASKPASS" "Password: " && ! "$GIT_ASKPASS" "Username: "`)
+	out, err := cmd.Output(askpass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "secret\n" {
+		t.Errorf("want answer printed, got %q", out)
+	}
+	if want := []string{"Password: ", "Username: "}; !reflect.DeepEqual(prompts, want) {
+		t.Errorf("want prompts %q, got %q", want, prompts)
+	}
+}
+
+func TestForwardSSHAgent(t *testing.T) {
+	agent := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", agent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	cmd := helperCommand(t, "sleep", "10s")
+	if err := cmd.Start(exec.Setenv("SSH_AUTH_SOCK", agent), exec.ForwardSSHAgent()); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+	var sock string
+	for _, kv := range cmd.Env {
+		if strings.HasPrefix(kv, "SSH_AUTH_SOCK=") {
+			sock = strings.TrimPrefix(kv, "SSH_AUTH_SOCK=")
+		}
+	}
+	if sock == agent {
+		t.Fatal("SSH_AUTH_SOCK not replaced")
+	}
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "ping")
+	b := make([]byte, 4)
+	if _, err := io.ReadFull(conn, b); err != nil || string(b) != "ping" {
+		t.Errorf("want ping echoed by agent, got %q, %v", b, err)
+	}
+}
+
+func TestMonitorResources(t *testing.T) {
+	cmd := helperCommand(t, "sleep", "100ms")
+	if err := cmd.Run(exec.MonitorResources(10 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	samples := cmd.ResourceSamples()
+	if len(samples) < 2 {
+		t.Fatalf("want several samples, got %d", len(samples))
+	}
+	if cmd.PeakRSS() == 0 || samples[0].FDs < 3 {
+		t.Errorf("want RSS and at least 3 open files, got %+v", samples[0])
+	}
+}
+
+func TestCPULimit(t *testing.T) {
+	if err := helperCommand(t, "sleep", "300ms").Run(exec.CPULimit(100 * time.Millisecond)); err != nil {
+		t.Errorf("sleep: %v", err)
+	}
+	start := time.Now()
+	if err := helperCommand(t, "spin", "10s").Run(exec.CPULimit(100 * time.Millisecond)); err != exec.ErrCPULimit {
+		t.Errorf("spin: want ErrCPULimit, got %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("spin: killed after %v", d)
+	}
+}
+
+func TestSeccomp(t *testing.T) {
+	if !exec.Capabilities().Seccomp {
+		t.Skip("skipping test; seccomp filters not supported")
+	}
+	path := filepath.Join(t.TempDir(), "out")
+	out, err := helperCommand(t, "echo", "hello").Output(exec.Seccomp(exec.SeccompDenylist("ptrace")))
+	if err != nil || string(out) != "hello\n" {
+		t.Fatalf("echo: want hello, got %q, %v", out, err)
+	}
+	var stderr bytes.Buffer
+	err = exec.Command("mkdir", path).Run(exec.Stderr(&stderr), exec.Seccomp(exec.SeccompDenylist("mkdir", "mkdirat")))
+	if err == nil || !strings.Contains(stderr.String(), "not permitted") {
+		t.Errorf("mkdir: want EPERM, got %v: %s", err, stderr.Bytes())
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("want %s not to have been written, got %v", path, err)
+	}
+	var p exec.SeccompProfile
+	if err := json.Unmarshal([]byte(`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"names": ["openat"], "action": "SCMP_ACT_NOTIFY"}]}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if err := helperCommand(t, "echo").Run(exec.Seccomp(&p)); err == nil || !strings.Contains(err.Error(), "unsupported action") {
+		t.Errorf("want unsupported action error, got %v", err)
+	}
+}
+
+func TestUnshare(t *testing.T) {
+	if !exec.Capabilities().Namespaces {
+		t.Skip("skipping test; namespaces not available")
+	}
+	out, err := exec.Command("/bin/sh", "-c", "echo $$; id -u; readlink /proc/self/ns/net").Output(exec.Unshare(exec.NewUser, exec.NewPID, exec.NewNet))
+	if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EINVAL) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostNet, err := os.Readlink("/proc/self/ns/net")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 3 || lines[0] != "1" || lines[1] != "0" || lines[2] == hostNet {
+		t.Errorf("want pid 1, uid 0 and a new network namespace, got %q (host %s)", lines, hostNet)
+	}
+	if err := exec.Command("true").Run(exec.MapUsers(exec.IDMap{Inside: 0, Outside: os.Getuid(), Size: 1})); err == nil {
+		t.Error("MapUsers without NewUser: want error")
+	}
+}
+
+func TestLandlock(t *testing.T) {
+	if !exec.Capabilities().Landlock {
+		t.Skip("skipping test; Landlock not enabled")
+	}
+	ro, rw := t.TempDir(), t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(ro, "in"), []byte("input\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	libs := []string{"/usr", "/lib", "/lib64", "/bin", "/etc"}
+	var existing []string
+	for _, l := range libs {
+		if _, err := os.Stat(l); err == nil {
+			existing = append(existing, l)
+		}
+	}
+	script := "cat " + ro + "/in > " + rw + "/out && ! touch " + ro + "/denied 2>/dev/null && ! cat /proc/self/status >/dev/null 2>&1"
+	var stderr bytes.Buffer
+	err := exec.Command("/bin/sh", "-c", script).Run(exec.Stderr(&stderr), exec.Landlock(append(existing, ro), []string{rw}))
+	if err != nil {
+		t.Fatalf("%v: %s", err, stderr.Bytes())
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(rw, "out")); err != nil || string(b) != "input\n" {
+		t.Errorf("want input copied to rw, got %q, %v", b, err)
+	}
+}
+
+func TestDropCapabilities(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("skipping test; not root")
+	}
+	out, err := exec.Command("/bin/sh", "-c", "grep -E '^Cap(Eff|Bnd|Amb)' /proc/self/status").Output(exec.DropCapabilities(exec.CapNetBindService))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if f := strings.Fields(line); len(f) != 2 || f[1] != "0000000000000400" {
+			t.Errorf("want only CAP_NET_BIND_SERVICE, got %q", line)
+		}
+	}
+}
+
+func TestSysProcAttr(t *testing.T) {
+	cmd := exec.Command("/bin/sh", "-c", "read pid comm state ppid pgrp rest < /proc/self/stat; echo $pid $pgrp")
+	var called bool
+	out, err := cmd.Output(exec.NewProcessGroup(), exec.Pdeathsig(syscall.SIGTERM), exec.SysProcAttr(func(attr *syscall.SysProcAttr) {
+		called = attr.Setpgid
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := strings.Fields(string(out)); len(f) != 2 || f[0] != f[1] {
+		t.Errorf("want child to lead its process group, got pid and pgrp %q", out)
+	}
+	if !called || cmd.SysProcAttr.Pdeathsig != syscall.SIGTERM {
+		t.Errorf("want Setpgid and Pdeathsig set, got %+v", cmd.SysProcAttr)
+	}
+}
+
+func TestKillOnParentDeath(t *testing.T) {
+	// the child must outlive the thread of the goroutine starting it,
+	// which is ended as it exits locked, unless it is the main thread.
+	started := make(chan *exec.Cmd)
+	var start func()
+	start = func() {
+		runtime.LockOSThread()
+		if syscall.Gettid() == os.Getpid() {
+			done := make(chan bool)
+			go func() { start(); close(done) }()
+			<-done
+			runtime.UnlockOSThread()
+			return
+		}
+		cmd := exec.Command("sleep", "0.5")
+		if err := cmd.Start(exec.KillOnParentDeath(syscall.SIGKILL)); err != nil {
+			t.Error(err)
+			cmd = nil
+		}
+		started <- cmd
+	}
+	go start()
+	cmd := <-started
+	if cmd == nil {
+		return
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Errorf("want child to survive its starting thread, got %v", err)
+	}
+	if err := exec.Command("true").Run(exec.KillOnParentDeath(os.Interrupt)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReaper(t *testing.T) {
+	out, err := helperCommand(t, "reaper").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "0\n" {
+		t.Errorf("want orphan reaped, got %q children", out)
+	}
+}
+
+func TestOverlayRoot(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("skipping test; not root")
+	}
+	// the work directory must be on another file system than /.
+	var st syscall.Statfs_t
+	if err := syscall.Statfs("/dev/shm", &st); err != nil || st.Type != 0x01021994 {
+		t.Skip("skipping test; /dev/shm is not a tmpfs")
+	}
+	work, err := ioutil.TempDir("/dev/shm", "overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(work)
+	name := filepath.Join("/etc", filepath.Base(work))
+	cmd := exec.Command("/bin/sh", "-c", "echo installed > "+name+" && cat "+name)
+	out, err := cmd.Output(exec.OverlayRoot("/", work))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "installed\n" {
+		t.Errorf("want %q, got %q", "installed\n", out)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		os.Remove(name)
+		t.Errorf("want %s unchanged on the host, got %v", name, err)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(cmd.UpperDir(), name)); err != nil || string(b) != "installed\n" {
+		t.Errorf("want %s in the upper directory, got %q, %v", name, b, err)
+	}
+}
@@ -0,0 +1,110 @@
+package exectest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/exec"
+)
+
+// FakeClock is an exec.Clock whose time only moves when Advance is
+// called, so timeouts and backoff may be tested deterministically.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	seq    int
+	timers []*fakeTimer
+}
+
+// NewFakeClock returns a FakeClock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the clock's current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc schedules f to be called once the clock has been advanced by
+// at least d.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) exec.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{c: c, f: f}
+	c.schedule(t, d)
+	return t
+}
+
+// Advance moves the clock forward by d, calling the functions of the
+// timers which expire, in the order they expire, before it returns.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+	for len(c.timers) > 0 && !c.timers[0].when.After(end) {
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		c.now = t.when
+		c.mu.Unlock()
+		t.f()
+		c.mu.Lock()
+	}
+	c.now = end
+	c.mu.Unlock()
+}
+
+// Pending returns the number of timers which have not yet expired or
+// been stopped. Tests may poll it to wait for the code under test to
+// schedule a timer before calling Advance.
+func (c *FakeClock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// schedule adds t, which must not be pending, to expire after d.
+func (c *FakeClock) schedule(t *fakeTimer, d time.Duration) {
+	c.seq++
+	t.when, t.seq = c.now.Add(d), c.seq
+	c.timers = append(c.timers, t)
+	sort.Slice(c.timers, func(i, j int) bool {
+		a, b := c.timers[i], c.timers[j]
+		return a.when.Before(b.when) || a.when.Equal(b.when) && a.seq < b.seq
+	})
+}
+
+// remove removes t from the pending timers, reporting whether it was
+// pending.
+func (c *FakeClock) remove(t *fakeTimer) bool {
+	for i, p := range c.timers {
+		if p == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTimer struct {
+	c    *FakeClock
+	f    func()
+	when time.Time
+	seq  int // orders timers which expire at the same time
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	return t.c.remove(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	pending := t.c.remove(t)
+	t.c.schedule(t, d)
+	return pending
+}
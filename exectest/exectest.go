@@ -0,0 +1,149 @@
+// Package exectest provides utilities for testing code which runs
+// commands with package exec.
+package exectest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Grace is how long VerifyNoLeaks waits for leaked resources to be
+// released before failing the test.
+var Grace = time.Second
+
+// VerifyNoLeaks fails t if, when it ends, a child process started since
+// VerifyNoLeaks was called is still running or has not been waited for,
+// a goroutine copying its stdio is still running, or a pipe opened since
+// has not been closed. Processes and pipes are only checked on Linux.
+// It should be called at the start of the test, and is not suitable for
+// parallel tests.
+func VerifyNoLeaks(t testing.TB) {
+	t.Helper()
+	before := snapshot()
+	t.Cleanup(func() {
+		t.Helper()
+		var leaks []string
+		for deadline := time.Now().Add(Grace); ; time.Sleep(10 * time.Millisecond) {
+			leaks = snapshot().leaked(before)
+			if len(leaks) == 0 || time.Now().After(deadline) {
+				break
+			}
+		}
+		for _, leak := range leaks {
+			t.Errorf("exectest: leaked %s", leak)
+		}
+	})
+}
+
+// state is the set of resources in use at one time, each described by
+// its key.
+type state struct {
+	children   map[string]string
+	goroutines map[string]string
+	pipes      map[string]string
+}
+
+func snapshot() state {
+	return state{children: children(), goroutines: goroutines(), pipes: pipes()}
+}
+
+// leaked describes the resources in s which were not in before.
+func (s state) leaked(before state) []string {
+	var leaks []string
+	for _, m := range [][2]map[string]string{
+		{s.children, before.children},
+		{s.goroutines, before.goroutines},
+		{s.pipes, before.pipes},
+	} {
+		for k, desc := range m[0] {
+			if _, ok := m[1][k]; !ok {
+				leaks = append(leaks, desc)
+			}
+		}
+	}
+	return leaks
+}
+
+// children returns the child processes of this process, by pid.
+func children() map[string]string {
+	m := map[string]string{}
+	if runtime.GOOS != "linux" {
+		return m
+	}
+	dirs, _ := filepath.Glob("/proc/[0-9]*/stat")
+	ppid := strconv.Itoa(os.Getpid())
+	for _, path := range dirs {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		// pid (comm) state ppid ...
+		i := bytes.LastIndexByte(b, ')')
+		if i < 0 {
+			continue
+		}
+		fields := strings.Fields(string(b[i+1:]))
+		if len(fields) < 2 || fields[1] != ppid {
+			continue
+		}
+		pid := filepath.Base(filepath.Dir(path))
+		desc := fmt.Sprintf("child process %s %s", pid, b[bytes.IndexByte(b, '(')+1:i])
+		if fields[0] == "Z" {
+			desc += " which was not waited for"
+		}
+		m[pid] = desc
+	}
+	return m
+}
+
+var goroutineHeader = regexp.MustCompile(`^goroutine (\d+) `)
+
+// goroutines returns the goroutines running code from os/exec or package
+// exec, by id.
+func goroutines() map[string]string {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	m := map[string]string{}
+	for _, g := range strings.Split(string(buf), "\n\n") {
+		id := goroutineHeader.FindStringSubmatch(g)
+		if id == nil || strings.Contains(g, "exectest.") {
+			continue
+		}
+		if strings.Contains(g, "os/exec.") || strings.Contains(g, "github.com/pkg/exec.") {
+			m[id[1]] = "goroutine " + id[1] + ":\n" + g
+		}
+	}
+	return m
+}
+
+// pipes returns the pipes open in this process, by file descriptor.
+func pipes() map[string]string {
+	m := map[string]string{}
+	if runtime.GOOS != "linux" {
+		return m
+	}
+	fds, _ := ioutil.ReadDir("/proc/self/fd")
+	for _, fd := range fds {
+		target, err := os.Readlink(filepath.Join("/proc/self/fd", fd.Name()))
+		if err == nil && strings.HasPrefix(target, "pipe:") {
+			m[fd.Name()+target] = fmt.Sprintf("pipe %s at fd %s", target, fd.Name())
+		}
+	}
+	return m
+}
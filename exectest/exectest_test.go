@@ -0,0 +1,86 @@
+package exectest_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/exec"
+	"github.com/pkg/exec/exectest"
+)
+
+// recorder is a testing.TB which records errors and cleanup functions.
+type recorder struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (r *recorder) Helper()           {}
+func (r *recorder) Cleanup(fn func()) { r.cleanups = append(r.cleanups, fn) }
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) finish() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
+
+func TestVerifyNoLeaks(t *testing.T) {
+	r := &recorder{TB: t}
+	exectest.VerifyNoLeaks(r)
+	if err := exec.Command(os.Args[0], "-test.run=^$").Run(); err != nil {
+		t.Fatal(err)
+	}
+	r.finish()
+	if len(r.errors) > 0 {
+		t.Errorf("want no leaks, got %q", r.errors)
+	}
+}
+
+func TestVerifyNoLeaksFindsRunningChild(t *testing.T) {
+	exectest.Grace = 0
+	defer func() { exectest.Grace = time.Second }()
+	r := &recorder{TB: t}
+	exectest.VerifyNoLeaks(r)
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(exec.Stdout(&strings.Builder{})); err != nil {
+		t.Skip(err)
+	}
+	r.finish()
+	cmd.Process.Kill()
+	cmd.Wait()
+	if len(r.errors) == 0 {
+		t.Error("want leaked child process or goroutine reported")
+	}
+}
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := exectest.NewFakeClock(start)
+	var fired []string
+	clk.AfterFunc(2*time.Second, func() { fired = append(fired, "b") })
+	clk.AfterFunc(time.Second, func() { fired = append(fired, "a") })
+	stopped := clk.AfterFunc(time.Second, func() { fired = append(fired, "stopped") })
+	if !stopped.Stop() {
+		t.Error("want Stop to report a pending timer")
+	}
+	clk.Advance(time.Second)
+	if strings.Join(fired, ",") != "a" {
+		t.Errorf("after 1s: want a fired, got %q", fired)
+	}
+	clk.Advance(time.Second)
+	if strings.Join(fired, ",") != "a,b" {
+		t.Errorf("after 2s: want a,b fired, got %q", fired)
+	}
+	if got := clk.Now(); !got.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("want now %v, got %v", start.Add(2*time.Second), got)
+	}
+	if clk.Pending() != 0 {
+		t.Errorf("want no pending timers, got %d", clk.Pending())
+	}
+}
@@ -0,0 +1,166 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// ExpandArgs replaces references to variables in the command's
+// arguments, as $KEY or ${KEY}, with their values in the child's
+// environment, just before the command starts. Undefined variables are
+// replaced by "", and $$ by $. This suits commands read from
+// configuration files, whose arguments may contain placeholders; unlike
+// a shell, it does not split the values into words or interpret them in
+// any other way.
+//
+// The expansion runs in turn with the work other options do as the
+// command starts, so it sees variables set then, such as TMPDIR by
+// TempDir and TRACEPARENT by WithTracing, only from options applied
+// before it. ExpandArgs should usually be applied last.
+//
+//	exec.Command("rsync", "-a", "${SRC}/", "${HOST}:/srv").Run(exec.EnvFile(".env"), exec.ExpandArgs())
+func ExpandArgs() func(*Cmd) error {
+	return Describe("ExpandArgs", nil, func(c *Cmd) error {
+		c.starting = append(c.starting, func(c *Cmd) error {
+			expandArgs(c, c.getenv)
+			return nil
+		})
+		return nil
+	})
+}
+
+// ExpandArgsFrom replaces references to variables in the command's
+// arguments, as ExpandArgs does, with their values in vars rather than
+// in the child's environment.
+func ExpandArgsFrom(vars map[string]string) func(*Cmd) error {
+	return Describe("ExpandArgsFrom", []Param{{"vars", vars}}, func(c *Cmd) error {
+		c.starting = append(c.starting, func(c *Cmd) error {
+			expandArgs(c, func(key string) string { return vars[key] })
+			return nil
+		})
+		return nil
+	})
+}
+
+// expandArgs expands the variables in the command's arguments, other
+// than argv[0], with lookup.
+func expandArgs(c *Cmd, lookup func(string) string) {
+	mapping := func(key string) string {
+		if key == "$" {
+			return "$"
+		}
+		return lookup(key)
+	}
+	for i := 1; i < len(c.Args); i++ {
+		c.Args[i] = os.Expand(c.Args[i], mapping)
+	}
+}
+
+// ExpandGlobs replaces those of the command's arguments which are glob
+// patterns, such as *.log, with the names of the files matching them,
+// in the manner of filepath.Glob, just before the command starts.
+// Patterns match files in the command's working directory, and are
+// replaced by relative names; a pattern which is absolute or refers to
+// .. is reported as an error, so that it cannot walk the rest of the
+// file system. Names which begin with - are prefixed with ./ so that
+// they are not taken for options. As in a shell, a pattern which matches
+// no files is left unchanged. Unlike a shell, no other expansion is
+// done.
+//
+//	exec.Command("gzip", "-9", "*.log").Run(exec.Dir(logDir), exec.ExpandGlobs())
+func ExpandGlobs() func(*Cmd) error {
+	return Describe("ExpandGlobs", nil, func(c *Cmd) error {
+		c.starting = append(c.starting, func(c *Cmd) error {
+			args := c.Args[:1:1]
+			for _, arg := range c.Args[1:] {
+				matches, err := glob(c.Dir, arg)
+				if err != nil {
+					return err
+				}
+				if len(matches) == 0 {
+					matches = []string{arg}
+				}
+				args = append(args, matches...)
+			}
+			c.Args = args
+			return nil
+		})
+		return nil
+	})
+}
+
+// glob returns the files matching pattern, relative to dir, or nil if
+// pattern is not a glob pattern.
+func glob(dir, pattern string) ([]string, error) {
+	if !strings.ContainsAny(pattern, "*?[") {
+		return nil, nil
+	}
+	if filepath.IsAbs(pattern) || filepath.VolumeName(pattern) != "" {
+		return nil, fmt.Errorf("exec: ExpandGlobs: pattern %q is not relative", pattern)
+	}
+	for _, elem := range strings.Split(filepath.ToSlash(pattern), "/") {
+		if elem == ".." {
+			return nil, fmt.Errorf("exec: ExpandGlobs: pattern %q leaves the working directory", pattern)
+		}
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	for i, m := range matches {
+		if dir != "" {
+			if rel, err := filepath.Rel(dir, m); err == nil {
+				m = rel
+			}
+		}
+		if strings.HasPrefix(m, "-") {
+			m = "." + string(filepath.Separator) + m
+		}
+		matches[i] = m
+	}
+	return matches, err
+}
+
+// ExpandTilde replaces a leading ~ in the command's arguments with the
+// current user's home directory, and ~name with that of the user name,
+// where it is followed by a path separator or ends the argument, just
+// before the command starts. Arguments naming unknown users are left
+// unchanged.
+//
+//	exec.Command("ls", "~/src").Run(exec.ExpandTilde())
+func ExpandTilde() func(*Cmd) error {
+	return Describe("ExpandTilde", nil, func(c *Cmd) error {
+		c.starting = append(c.starting, func(c *Cmd) error {
+			for i := 1; i < len(c.Args); i++ {
+				c.Args[i] = expandTilde(c.Args[i])
+			}
+			return nil
+		})
+		return nil
+	})
+}
+
+func expandTilde(arg string) string {
+	if !strings.HasPrefix(arg, "~") {
+		return arg
+	}
+	name, rest := arg[1:], ""
+	if i := strings.IndexAny(name, `/`+string(filepath.Separator)); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+	var home string
+	if name == "" {
+		h, err := os.UserHomeDir()
+		if err != nil {
+			return arg
+		}
+		home = h
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return arg
+		}
+		home = u.HomeDir
+	}
+	return home + rest
+}
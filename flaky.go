@@ -0,0 +1,134 @@
+package exec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FlakeDetector finds commands which are nondeterministic, by recording
+// the exit codes of the commands run with its Record option and reporting
+// those which exited differently when run identically. Commands are
+// identical if they share a program, arguments, working directory and
+// environment, other than the directory made by TempDir and the
+// variables set afresh for each run by Correlate, TempDir and
+// WithTracing. The zero value is ready to use.
+type FlakeDetector struct {
+	mu   sync.Mutex
+	runs map[string]*FlakyCommand // by fingerprint
+}
+
+// FlakyCommand describes the runs of an identical command recorded by a
+// FlakeDetector.
+type FlakyCommand struct {
+	Fingerprint string   // SHA-256 of the program, arguments, directory and environment
+	Args        []string // the command line, including the program
+	Dir         string
+	Runs        int
+	ExitCodes   map[int]int // number of runs by exit code, -1 if the command did not exit normally
+}
+
+// Record records the exit code of the command in d.
+func (d *FlakeDetector) Record() func(*Cmd) error {
+	return Describe("FlakeDetector.Record", nil, func(c *Cmd) error {
+		var fingerprint string
+		c.starting = append(c.starting, func(c *Cmd) error {
+			fingerprint = commandFingerprint(c)
+			return nil
+		})
+		c.finished = append(c.finished, func(c *Cmd) error {
+			if c.ProcessState == nil {
+				return nil // not started
+			}
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			if d.runs == nil {
+				d.runs = make(map[string]*FlakyCommand)
+			}
+			fc := d.runs[fingerprint]
+			if fc == nil {
+				fc = &FlakyCommand{
+					Fingerprint: fingerprint,
+					Args:        c.redactAll(c.Args),
+					Dir:         c.Dir,
+					ExitCodes:   make(map[int]int),
+				}
+				d.runs[fingerprint] = fc
+			}
+			fc.Runs++
+			fc.ExitCodes[c.ProcessState.ExitCode()]++
+			return nil
+		})
+		return nil
+	})
+}
+
+// Flaky returns the commands which have exited with more than one exit
+// code, most runs first.
+func (d *FlakeDetector) Flaky() []FlakyCommand {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var flaky []FlakyCommand
+	for _, fc := range d.runs {
+		if len(fc.ExitCodes) < 2 {
+			continue
+		}
+		f := *fc
+		f.ExitCodes = make(map[int]int, len(fc.ExitCodes))
+		for code, n := range fc.ExitCodes {
+			f.ExitCodes[code] = n
+		}
+		flaky = append(flaky, f)
+	}
+	sort.Slice(flaky, func(i, j int) bool {
+		if flaky[i].Runs != flaky[j].Runs {
+			return flaky[i].Runs > flaky[j].Runs
+		}
+		return flaky[i].Fingerprint < flaky[j].Fingerprint
+	})
+	return flaky
+}
+
+// commandFingerprint identifies the command c will run. The directory
+// made by TempDir, and variables which options in this package set to a
+// new value for every run, are ignored.
+func commandFingerprint(c *Cmd) string {
+	var env []string
+	for _, kv := range c.Env {
+		key := kv
+		if i := strings.IndexByte(kv, '='); i >= 0 {
+			key = kv[:i]
+		}
+		switch {
+		case key == RunIDEnv, key == ParentRunIDEnv, key == TraceParentEnv:
+			continue
+		case key == "TMPDIR" && c.tempDir != "":
+			continue
+		}
+		env = append(env, kv)
+	}
+	dir := c.Dir
+	if dir == c.tempDir {
+		dir = ""
+	}
+	h := sha256.New()
+	for _, s := range append([]string{c.Path, dir, envSHA256(env)}, c.Args...) {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Flakiness returns the fraction of the runs of fc which did not exit
+// with its most common exit code.
+func (fc FlakyCommand) Flakiness() float64 {
+	max := 0
+	for _, n := range fc.ExitCodes {
+		if n > max {
+			max = n
+		}
+	}
+	return 1 - float64(max)/float64(fc.Runs)
+}
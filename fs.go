@@ -0,0 +1,125 @@
+package exec
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// StdinFS specifies the process's standard input as the named file in
+// fsys, which is opened each time a command is started.
+func StdinFS(fsys fs.FS, name string) func(*Cmd) error {
+	return Describe("StdinFS", []Param{{"fsys", fsys}, {"name", name}}, StdinGenerator(func() (io.Reader, error) {
+		return fsys.Open(name)
+	}))
+}
+
+// FileArgs copies the named files in fsys, such as an embed.FS, to a
+// temporary directory and appends their paths to the command's
+// arguments, so external programs can operate on files which are not on
+// disk. The files keep their paths relative to the directory, which is
+// removed once the command has exited.
+func FileArgs(fsys fs.FS, names ...string) func(*Cmd) error {
+	return Describe("FileArgs", []Param{{"fsys", fsys}, {"names", names}}, func(c *Cmd) error {
+		for _, name := range names {
+			if !fs.ValidPath(name) {
+				return &fs.PathError{Op: "FileArgs", Path: name, Err: fs.ErrInvalid}
+			}
+		}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			dir, err := ioutil.TempDir("", "pkg-exec-fs-")
+			if err != nil {
+				return err
+			}
+			c.finished = append(c.finished, func(*Cmd) error {
+				return os.RemoveAll(dir)
+			})
+			for _, name := range names {
+				path := filepath.Join(dir, filepath.FromSlash(name))
+				if err := copyFromFS(fsys, name, path); err != nil {
+					return err
+				}
+				c.Args = append(c.Args, path)
+			}
+			return nil
+		})
+		return nil
+	})
+}
+
+// OutputFiles declares files written by the command, so the caller need
+// not manage temporary files. For each of names, the path of a file in a
+// fresh temporary directory is appended to the command's arguments, in
+// the manner of FileArgs. Once the command has exited, those files it
+// wrote are read into files, keyed by name, and the directory is removed.
+//
+//	out := map[string][]byte{}
+//	exec.Command("pandoc", "in.md", "-o").Run(exec.OutputFiles(out, "out.pdf"))
+func OutputFiles(files map[string][]byte, names ...string) func(*Cmd) error {
+	return Describe("OutputFiles", []Param{{"files", files}, {"names", names}}, func(c *Cmd) error {
+		if files == nil {
+			return errors.New("exec: OutputFiles map must not be nil")
+		}
+		for _, name := range names {
+			if !fs.ValidPath(name) {
+				return &fs.PathError{Op: "OutputFiles", Path: name, Err: fs.ErrInvalid}
+			}
+		}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			dir, err := ioutil.TempDir("", "pkg-exec-out-")
+			if err != nil {
+				return err
+			}
+			paths := make([]string, len(names))
+			for i, name := range names {
+				paths[i] = filepath.Join(dir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(paths[i]), 0700); err != nil {
+					os.RemoveAll(dir)
+					return err
+				}
+			}
+			c.Args = append(c.Args, paths...)
+			c.finished = append(c.finished, func(*Cmd) error {
+				defer os.RemoveAll(dir)
+				for i, name := range names {
+					b, err := ioutil.ReadFile(paths[i])
+					if os.IsNotExist(err) {
+						continue
+					}
+					if err != nil {
+						return err
+					}
+					files[name] = b
+				}
+				return nil
+			})
+			return nil
+		})
+		return nil
+	})
+}
+
+// copyFromFS copies the file name in fsys to path, creating any missing
+// directories.
+func copyFromFS(fsys fs.FS, name, path string) error {
+	src, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	dst, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
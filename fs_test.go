@@ -0,0 +1,46 @@
+package exec_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/pkg/exec"
+)
+
+func TestFileArgs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt":     {Data: []byte("a\n")},
+		"dir/b.txt": {Data: []byte("b\n")},
+	}
+	out, err := helperCommand(t, "cat").Output(exec.FileArgs(fsys, "a.txt", "dir/b.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "a\nb\n" {
+		t.Errorf("want contents of both files, got %q", out)
+	}
+	if err := helperCommand(t, "cat").Run(exec.FileArgs(fsys, "../a.txt")); err == nil {
+		t.Error("want error for invalid path")
+	}
+}
+
+func TestStdinFS(t *testing.T) {
+	fsys := fstest.MapFS{"in.txt": {Data: []byte("hello")}}
+	out, err := helperCommand(t, "cat").Output(exec.StdinFS(fsys, "in.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "hello" {
+		t.Errorf("want %q, got %q", "hello", out)
+	}
+}
+
+func TestOutputFiles(t *testing.T) {
+	out := map[string][]byte{}
+	if err := helperCommand(t, "writefile", "hello").Run(exec.OutputFiles(out, "dir/out.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(out["dir/out.txt"]); got != "hello" {
+		t.Errorf("want %q, got %q", "hello", got)
+	}
+}
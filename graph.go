@@ -0,0 +1,275 @@
+package exec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrDependencyFailed is returned, wrapped, as the error of a task which
+// was not run because one of its dependencies failed.
+var ErrDependencyFailed = errors.New("exec: dependency failed")
+
+// Task is a command in a Graph.
+type Task struct {
+	Name string
+	Spec Spec
+
+	// Deps names the tasks which must complete before this task starts.
+	Deps []string
+
+	// Inputs and Outputs name the artifacts, usually file paths, the task
+	// consumes and produces. A task depends on the tasks which produce
+	// its inputs, in addition to those in Deps.
+	Inputs, Outputs []string
+
+	// UpToDate, if non nil, is called once the task's dependencies have
+	// completed. If it reports true the task is not run.
+	UpToDate func() bool
+}
+
+// TaskResult records the outcome of running a Task.
+type TaskResult struct {
+	Name     string
+	Err      error  // nil if the task succeeded
+	Skipped  bool   // the task was up to date and was not run
+	Output   []byte // combined stdout and stderr, if Graph.CaptureOutput is set
+	Start    time.Time
+	Duration time.Duration
+}
+
+// Graph runs a set of tasks in dependency order, running each task as
+// soon as its dependencies have completed.
+type Graph struct {
+	Tasks []Task
+
+	// Parallelism limits the number of tasks run at once.
+	// If zero, there is no limit.
+	Parallelism int
+
+	// KeepGoing continues to start tasks which do not depend on a failed
+	// task. By default no tasks are started after the first failure.
+	KeepGoing bool
+
+	// CaptureOutput records the combined output of each task in its
+	// result, in addition to passing it to any writers set by the
+	// task's options.
+	CaptureOutput bool
+}
+
+// Run runs the tasks in g, returning their results in the order they
+// completed. Every task has a result; those which were not run have an
+// error wrapping ErrDependencyFailed. The returned error is that of the
+// task which failed, or a MultiError of the errors of each task which
+// failed, in the order they completed, if there were several. Tasks
+// which were not run do not contribute to it. An error is returned
+// without running any tasks if the graph refers to unknown tasks or
+// contains a cycle.
+func (g *Graph) Run() ([]TaskResult, error) {
+	deps, err := g.resolve()
+	if err != nil {
+		return nil, err
+	}
+	n := len(g.Tasks)
+	pending := make([]int, n) // number of unfinished dependencies
+	dependents := make([][]int, n)
+	for i, ds := range deps {
+		pending[i] = len(ds)
+		for _, d := range ds {
+			dependents[d] = append(dependents[d], i)
+		}
+	}
+	var ready []int
+	for i := range g.Tasks {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	type result struct {
+		i int
+		TaskResult
+	}
+	done := make(chan result)
+	failed := make([]bool, n)
+	results := make([]TaskResult, 0, n)
+	var firstErr error
+	var errs []error // of the tasks which were run
+	running := 0
+
+	complete := func(i int, r TaskResult) {
+		results = append(results, r)
+		if r.Err != nil {
+			failed[i] = true
+			if firstErr == nil {
+				firstErr = r.Err
+			}
+		}
+		for _, j := range dependents[i] {
+			if pending[j]--; pending[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+
+	for len(results) < n {
+		for len(ready) > 0 && (g.Parallelism <= 0 || running < g.Parallelism) {
+			i := ready[0]
+			ready = ready[1:]
+			t := g.Tasks[i]
+			if dep := failedDep(deps[i], failed, g.Tasks); dep != "" {
+				complete(i, TaskResult{Name: t.Name, Err: fmt.Errorf("%w: %s", ErrDependencyFailed, dep)})
+				continue
+			}
+			if firstErr != nil && !g.KeepGoing {
+				complete(i, TaskResult{Name: t.Name, Err: fmt.Errorf("%w: %v", ErrDependencyFailed, firstErr)})
+				continue
+			}
+			running++
+			go func(i int, t Task) {
+				start := time.Now()
+				if t.UpToDate != nil && t.UpToDate() {
+					done <- result{i, TaskResult{Name: t.Name, Skipped: true, Start: start}}
+					return
+				}
+				var out bytes.Buffer
+				var opts []func(*Cmd) error
+				if g.CaptureOutput {
+					opts = append(opts, captureOutput(&out))
+				}
+				err := t.Spec.Command().Run(opts...)
+				r := TaskResult{Name: t.Name, Err: err, Start: start, Duration: time.Since(start)}
+				if g.CaptureOutput {
+					r.Output = out.Bytes()
+				}
+				done <- result{i, r}
+			}(i, t)
+		}
+		if running == 0 {
+			break
+		}
+		r := <-done
+		running--
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+		complete(r.i, r.TaskResult)
+	}
+	return results, joinErrors(errs)
+}
+
+func failedDep(deps []int, failed []bool, tasks []Task) string {
+	for _, d := range deps {
+		if failed[d] {
+			return tasks[d].Name
+		}
+	}
+	return ""
+}
+
+// resolve returns, for each task, the indexes of the tasks it depends on.
+func (g *Graph) resolve() ([][]int, error) {
+	byName := make(map[string]int)
+	producer := make(map[string]int)
+	for i, t := range g.Tasks {
+		if _, ok := byName[t.Name]; ok {
+			return nil, fmt.Errorf("exec: duplicate task %q", t.Name)
+		}
+		byName[t.Name] = i
+		for _, out := range t.Outputs {
+			if j, ok := producer[out]; ok {
+				return nil, fmt.Errorf("exec: %q produced by both %q and %q", out, g.Tasks[j].Name, t.Name)
+			}
+			producer[out] = i
+		}
+	}
+	deps := make([][]int, len(g.Tasks))
+	for i, t := range g.Tasks {
+		seen := make(map[int]bool)
+		add := func(j int) {
+			if j != i && !seen[j] {
+				seen[j] = true
+				deps[i] = append(deps[i], j)
+			}
+		}
+		for _, name := range t.Deps {
+			j, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("exec: task %q depends on unknown task %q", t.Name, name)
+			}
+			add(j)
+		}
+		for _, in := range t.Inputs {
+			if j, ok := producer[in]; ok {
+				add(j)
+			}
+		}
+	}
+	if cycle := findCycle(deps, g.Tasks); cycle != nil {
+		return nil, fmt.Errorf("exec: dependency cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return deps, nil
+}
+
+// findCycle returns the names of the tasks forming a cycle in deps, or
+// nil if there is none.
+func findCycle(deps [][]int, tasks []Task) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(deps))
+	var stack []int
+	var visit func(i int) []string
+	visit = func(i int) []string {
+		state[i] = visiting
+		stack = append(stack, i)
+		for _, d := range deps[i] {
+			switch state[d] {
+			case visiting:
+				var cycle []string
+				for k := len(stack) - 1; k >= 0; k-- {
+					cycle = append([]string{tasks[stack[k]].Name}, cycle...)
+					if stack[k] == d {
+						break
+					}
+				}
+				return append(cycle, tasks[d].Name)
+			case unvisited:
+				if cycle := visit(d); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = visited
+		return nil
+	}
+	for i := range deps {
+		if state[i] == unvisited {
+			if cycle := visit(i); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// captureOutput copies the child's stdout and stderr to w, as well as to
+// any writers already set.
+func captureOutput(w io.Writer) func(*Cmd) error {
+	return func(c *Cmd) error {
+		lw := &lockedWriter{w: w}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			wrapOutput(c, func(w io.Writer) io.Writer {
+				return io.MultiWriter(w, lw)
+			})
+			return nil
+		})
+		return nil
+	}
+}
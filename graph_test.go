@@ -0,0 +1,108 @@
+package exec_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pkg/exec"
+)
+
+func TestGraphOrder(t *testing.T) {
+	g := exec.Graph{Tasks: []exec.Task{
+		{Name: "link", Spec: helperSpec(t, "echo"), Inputs: []string{"a.o", "b.o"}},
+		{Name: "a", Spec: helperSpec(t, "echo"), Outputs: []string{"a.o"}, Deps: []string{"gen"}},
+		{Name: "b", Spec: helperSpec(t, "echo"), Outputs: []string{"b.o"}, Deps: []string{"gen"}},
+		{Name: "gen", Spec: helperSpec(t, "echo")},
+	}}
+	results, err := g.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	for _, r := range results {
+		order = append(order, r.Name)
+	}
+	if got := strings.Join(order, " "); got != "gen a b link" && got != "gen b a link" {
+		t.Errorf("want gen, then a and b, then link; got %s", got)
+	}
+}
+
+func TestGraphFailure(t *testing.T) {
+	g := exec.Graph{Tasks: []exec.Task{
+		{Name: "fail", Spec: helperSpec(t, "exit", "1")},
+		{Name: "after", Spec: helperSpec(t, "echo"), Deps: []string{"fail"}},
+	}}
+	results, err := g.Run()
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if len(results) != 2 || !errors.Is(results[1].Err, exec.ErrDependencyFailed) {
+		t.Errorf("want after to fail with ErrDependencyFailed, got %v", results)
+	}
+}
+
+func TestGraphKeepGoingErrors(t *testing.T) {
+	g := exec.Graph{KeepGoing: true, Tasks: []exec.Task{
+		{Name: "a", Spec: helperSpec(t, "exit", "1")},
+		{Name: "b", Spec: helperSpec(t, "exit", "2")},
+		{Name: "c", Spec: helperSpec(t, "echo")},
+		{Name: "after", Spec: helperSpec(t, "echo"), Deps: []string{"a"}},
+	}}
+	_, err := g.Run()
+	var errs exec.MultiError
+	if !errors.As(err, &errs) {
+		t.Fatalf("want MultiError, got %T: %v", err, err)
+	}
+	if len(errs) != 2 {
+		t.Errorf("want the errors of a and b, got %v", errs)
+	}
+	if errors.Is(err, exec.ErrDependencyFailed) {
+		t.Errorf("want only the errors of tasks which were run, got %v", errs)
+	}
+	if !strings.Contains(err.Error(), "(and 1 more error)") {
+		t.Errorf("Error: got %q", err.Error())
+	}
+}
+
+func TestGraphCycle(t *testing.T) {
+	g := exec.Graph{Tasks: []exec.Task{
+		{Name: "a", Deps: []string{"b"}},
+		{Name: "b", Deps: []string{"a"}},
+	}}
+	_, err := g.Run()
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("want cycle error, got %v", err)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	fail := helperSpec(t, "pipetest")
+	fail.Opts = append(fail.Opts, exec.Stdin(strings.NewReader("E:broken\nX\n")))
+	g := exec.Graph{
+		Tasks: []exec.Task{
+			{Name: "ok", Spec: helperSpec(t, "echo", "fine")},
+			{Name: "fail", Spec: fail, Deps: []string{"ok"}},
+		},
+		CaptureOutput: true,
+	}
+	results, err := g.Run()
+	if err == nil {
+		t.Fatal("want error")
+	}
+	var b bytes.Buffer
+	if err := exec.WriteJUnit(&b, "build", results); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<testsuite name="build" tests="2" failures="1" skipped="0"`,
+		`<testcase name="ok" classname="build"`,
+		`<failure message="exit status 1"`,
+		`<system-out>E:broken&#xA;</system-out>`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("want %s in report:\n%s", want, b.String())
+		}
+	}
+}
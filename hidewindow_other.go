@@ -0,0 +1,11 @@
+//go:build !windows
+// +build !windows
+
+package exec
+
+// HideWindow stops a console program run from a GUI application
+// flashing up a console window. Only Windows creates such windows, so
+// elsewhere it does nothing.
+func HideWindow() func(*Cmd) error {
+	return Describe("HideWindow", nil, func(*Cmd) error { return nil })
+}
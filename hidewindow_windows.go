@@ -0,0 +1,20 @@
+package exec
+
+import "syscall"
+
+// createNoWindow is the CREATE_NO_WINDOW process creation flag.
+const createNoWindow = 0x08000000
+
+// HideWindow stops a console program run from a GUI application
+// flashing up a console window, by creating it without one and hiding
+// any window it shows at start up.
+func HideWindow() func(*Cmd) error {
+	return Describe("HideWindow", nil, func(c *Cmd) error {
+		if c.SysProcAttr == nil {
+			c.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		c.SysProcAttr.HideWindow = true
+		c.SysProcAttr.CreationFlags |= createNoWindow
+		return nil
+	})
+}
@@ -0,0 +1,43 @@
+package exec
+
+import "strconv"
+
+// jailEnv and capsicumEnv are the environment variables in which Jail
+// and Capsicum pass their settings to the sandbox shim.
+const (
+	jailEnv     = "EXEC_JAIL"
+	capsicumEnv = "EXEC_CAPSICUM"
+)
+
+// Jail runs the child in the existing jail jid, as jexec does, which
+// requires root. The child starts in the jail's root directory, so Dir
+// is ignored, and the program is executed by its path as resolved on the
+// host, which must also exist in the jail.
+//
+// The child is attached to the jail by the sandbox shim, so the main
+// function must begin by calling Reexec.
+func Jail(jid int) func(*Cmd) error {
+	return Describe("Jail", []Param{{"jid", jid}}, func(c *Cmd) error {
+		c.starting = append(c.starting, func(c *Cmd) error {
+			return applyOptions(c, Setenv(jailEnv, strconv.Itoa(jid)))
+		})
+		return c.sandbox()
+	})
+}
+
+// Capsicum runs the child in Capsicum capability mode, in which it may
+// not access global namespaces such as the file system, and may only use
+// the file descriptors it inherits, such as its standard input and
+// output and ExtraFiles. The shared libraries of a dynamically linked
+// program are loaded from /lib, /usr/lib and /usr/local/lib.
+//
+// Capability mode is entered by the sandbox shim, so the main function
+// must begin by calling Reexec.
+func Capsicum() func(*Cmd) error {
+	return Describe("Capsicum", nil, func(c *Cmd) error {
+		c.starting = append(c.starting, func(c *Cmd) error {
+			return applyOptions(c, Setenv(capsicumEnv, "1"))
+		})
+		return c.sandbox()
+	})
+}
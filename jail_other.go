@@ -0,0 +1,20 @@
+//go:build !freebsd
+// +build !freebsd
+
+package exec
+
+// Jail runs the child in the existing FreeBSD jail jid. It is only
+// supported on FreeBSD.
+func Jail(jid int) func(*Cmd) error {
+	return Describe("Jail", []Param{{"jid", jid}}, func(*Cmd) error {
+		return notSupported("Jail")
+	})
+}
+
+// Capsicum runs the child in Capsicum capability mode. It is only
+// supported on FreeBSD.
+func Capsicum() func(*Cmd) error {
+	return Describe("Capsicum", nil, func(*Cmd) error {
+		return notSupported("Capsicum")
+	})
+}
@@ -0,0 +1,46 @@
+package exec
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// JSONError is returned by RunJSON when the output of the command could
+// not be decoded.
+type JSONError struct {
+	Err    error  // the error from encoding/json
+	Output []byte // the command's standard output
+
+	redact func(string) string // see RedactEnv
+}
+
+// maxJSONErrorOutput is the amount of output quoted by JSONError.Error.
+const maxJSONErrorOutput = 256
+
+func (e *JSONError) Error() string {
+	out := e.Output
+	if e.redact != nil {
+		out = []byte(e.redact(string(out)))
+	}
+	if len(out) > maxJSONErrorOutput {
+		out = append(out[:maxJSONErrorOutput:maxJSONErrorOutput], "..."...)
+	}
+	return fmt.Sprintf("exec: decoding JSON output: %v; output: %q", e.Err, out)
+}
+
+func (e *JSONError) Unwrap() error { return e.Err }
+
+// RunJSON runs the command and decodes its standard output, which must be
+// a single JSON value, into v. If the command does not exit cleanly its
+// error is returned and v is not modified. If the output cannot be
+// decoded a *JSONError holding the output is returned.
+func (c *Cmd) RunJSON(v interface{}, opts ...func(*Cmd) error) error {
+	out, err := c.Output(opts...)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(out, v); err != nil {
+		return &JSONError{Err: err, Output: out, redact: c.Redact}
+	}
+	return nil
+}
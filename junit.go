@@ -0,0 +1,79 @@
+package exec
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// WriteJUnit writes results, such as those returned by Graph.Run, to w as
+// a JUnit XML report with a test suite called name. Each task is a test
+// case; those which were up to date, or not run because a dependency
+// failed, are reported as skipped. The output of failed tasks, if
+// captured with Graph.CaptureOutput, is included in the report.
+func WriteJUnit(w io.Writer, name string, results []TaskResult) error {
+	suite := junitSuite{Name: name, Tests: len(results)}
+	var total time.Duration
+	for _, r := range results {
+		tc := junitCase{Name: r.Name, Classname: name, Time: junitSeconds(r.Duration)}
+		total += r.Duration
+		switch {
+		case r.Skipped:
+			tc.Skipped = &junitMessage{Message: "up to date"}
+			suite.Skipped++
+		case errors.Is(r.Err, ErrDependencyFailed):
+			tc.Skipped = &junitMessage{Message: r.Err.Error()}
+			suite.Skipped++
+		case r.Err != nil:
+			tc.Failure = &junitMessage{Message: r.Err.Error(), Type: fmt.Sprintf("%T", r.Err)}
+			tc.SystemOut = string(r.Output)
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Time = junitSeconds(total)
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+}
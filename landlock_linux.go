@@ -0,0 +1,181 @@
+package exec
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// landlockEnv is the environment variable in which Landlock passes the
+// paths the child may access to the sandbox shim.
+const landlockEnv = "EXEC_LANDLOCK_RULES"
+
+const (
+	sysLandlockAddRule      = 445
+	sysLandlockRestrictSelf = 446
+	landlockRulePathBeneath = 1
+	oPath                   = 0x200000 // O_PATH, missing from package syscall
+)
+
+// filesystem access rights, from linux/landlock.h, and the ABI version
+// which introduced them.
+const (
+	landlockExecute    = 1 << 0
+	landlockWriteFile  = 1 << 1
+	landlockReadFile   = 1 << 2
+	landlockReadDir    = 1 << 3
+	landlockRemoveDir  = 1 << 4
+	landlockRemoveFile = 1 << 5
+	landlockMakeChar   = 1 << 6
+	landlockMakeDir    = 1 << 7
+	landlockMakeReg    = 1 << 8
+	landlockMakeSock   = 1 << 9
+	landlockMakeFifo   = 1 << 10
+	landlockMakeBlock  = 1 << 11
+	landlockMakeSym    = 1 << 12
+	landlockRefer      = 1 << 13 // ABI 2
+	landlockTruncate   = 1 << 14 // ABI 3
+	landlockIoctlDev   = 1 << 15 // ABI 5
+
+	landlockRead     = landlockExecute | landlockReadFile | landlockReadDir
+	landlockFileOnly = landlockExecute | landlockWriteFile | landlockReadFile | landlockTruncate | landlockIoctlDev
+)
+
+// landlockRules are the paths passed to the sandbox shim.
+type landlockRules struct {
+	RO []string `json:"ro"`
+	RW []string `json:"rw"`
+}
+
+// Landlock confines the child's access to the file system to the files
+// and directory trees ro, which it may read and execute, and rw, which
+// it may also modify, using the Landlock LSM. The program itself may be
+// executed, but the dynamic loader and libraries it needs must be in
+// ro, for example "/usr" and "/lib". Relative paths are interpreted
+// relative to the current directory.
+//
+// Landlock is applied by the sandbox shim, as Seccomp is, so the main
+// function must begin by calling Reexec. If the kernel does not support
+// Landlock, an error wrapping ErrNotSupported is returned, see
+// Capabilities.
+//
+//	cmd.Run(exec.Landlock([]string{"/usr", "/lib", "/etc", input}, []string{outDir}))
+func Landlock(ro, rw []string) func(*Cmd) error {
+	return Describe("Landlock", []Param{{"ro", ro}, {"rw", rw}}, func(c *Cmd) error {
+		if !Capabilities().Landlock {
+			return fmt.Errorf("exec: Landlock %w: the Landlock LSM is not enabled in this kernel", ErrNotSupported)
+		}
+		var rules landlockRules
+		for _, p := range ro {
+			abs, err := filepath.Abs(p)
+			if err != nil {
+				return err
+			}
+			rules.RO = append(rules.RO, abs)
+		}
+		for _, p := range rw {
+			abs, err := filepath.Abs(p)
+			if err != nil {
+				return err
+			}
+			rules.RW = append(rules.RW, abs)
+		}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			path, err := filepath.Abs(filepath.Join(c.Dir, c.Path))
+			if filepath.IsAbs(c.Path) {
+				path, err = c.Path, nil
+			}
+			if err != nil {
+				return err
+			}
+			rules := rules
+			rules.RO = append(rules.RO[:len(rules.RO):len(rules.RO)], path)
+			b, err := json.Marshal(rules)
+			if err != nil {
+				return err
+			}
+			return applyOptions(c, Setenv(landlockEnv, string(b)))
+		})
+		return c.sandbox()
+	})
+}
+
+// landlockHandled returns the access rights known to the kernel's
+// version of the Landlock ABI, all of which are denied unless allowed.
+func landlockHandled() (uint64, error) {
+	abi, _, errno := syscall.Syscall(sysLandlockCreateRuleset, 0, 0, landlockCreateRulesetVersion)
+	if errno != 0 {
+		return 0, errno
+	}
+	handled := uint64(landlockMakeSym<<1 - 1)
+	if abi >= 2 {
+		handled |= landlockRefer
+	}
+	if abi >= 3 {
+		handled |= landlockTruncate
+	}
+	if abi >= 5 {
+		handled |= landlockIoctlDev
+	}
+	return handled, nil
+}
+
+// restrictLandlock restricts the current thread to the paths encoded in
+// rules by Landlock. The thread must have no_new_privs set.
+func restrictLandlock(encoded string) error {
+	var rules landlockRules
+	if err := json.Unmarshal([]byte(encoded), &rules); err != nil {
+		return err
+	}
+	handled, err := landlockHandled()
+	if err != nil {
+		return err
+	}
+	attr := handled // struct landlock_ruleset_attr, of which only handled_access_fs is set
+	fd, _, errno := syscall.Syscall(sysLandlockCreateRuleset, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("creating ruleset: %v", errno)
+	}
+	defer syscall.Close(int(fd))
+	add := func(path string, access uint64) error {
+		f, err := os.OpenFile(path, oPath|syscall.O_CLOEXEC, 0)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fi, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			access &= landlockFileOnly
+		}
+		// struct landlock_path_beneath_attr is packed.
+		var rule [12]byte
+		binary.LittleEndian.PutUint64(rule[:], access&handled)
+		binary.LittleEndian.PutUint32(rule[8:], uint32(f.Fd()))
+		_, _, errno := syscall.Syscall6(sysLandlockAddRule, fd, landlockRulePathBeneath, uintptr(unsafe.Pointer(&rule[0])), 0, 0, 0)
+		if errno != 0 {
+			return fmt.Errorf("adding rule for %s: %v", path, errno)
+		}
+		return nil
+	}
+	for _, p := range rules.RO {
+		if err := add(p, landlockRead); err != nil {
+			return err
+		}
+	}
+	for _, p := range rules.RW {
+		if err := add(p, handled); err != nil {
+			return err
+		}
+	}
+	if _, _, errno := syscall.Syscall(sysLandlockRestrictSelf, fd, 0, 0); errno != 0 {
+		return fmt.Errorf("restricting: %v", errno)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package exec
+
+// Landlock confines the child's access to the file system to the files
+// and directory trees ro and rw. It is only supported on Linux.
+func Landlock(ro, rw []string) func(*Cmd) error {
+	return Describe("Landlock", []Param{{"ro", ro}, {"rw", rw}}, func(*Cmd) error {
+		return notSupported("Landlock")
+	})
+}
@@ -0,0 +1,119 @@
+package exec
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// StdoutReader starts the command and returns a reader of its standard
+// output. Once the output is exhausted, Read returns the error from Wait
+// in place of io.EOF if the command did not exit cleanly. Close kills
+// the command if it is still running and waits for it to exit, so the
+// caller need only defer a call to Close.
+func (c *Cmd) StdoutReader(opts ...func(*Cmd) error) (io.ReadCloser, error) {
+	return c.outputReader(Stdout, opts...)
+}
+
+// StderrReader is like StdoutReader, but reads the command's standard
+// error.
+func (c *Cmd) StderrReader(opts ...func(*Cmd) error) (io.ReadCloser, error) {
+	return c.outputReader(Stderr, opts...)
+}
+
+func (c *Cmd) outputReader(stream func(io.Writer) func(*Cmd) error, opts ...func(*Cmd) error) (*outputReader, error) {
+	pr, pw := io.Pipe()
+	opts = append([]func(*Cmd) error{stream(pw)}, opts...)
+	if err := c.Start(opts...); err != nil {
+		return nil, err
+	}
+	r := &outputReader{c: c, pr: pr, done: make(chan struct{})}
+	go func() {
+		pw.CloseWithError(c.Wait())
+		close(r.done)
+	}()
+	return r, nil
+}
+
+// outputReader reads the output of a running command.
+type outputReader struct {
+	c    *Cmd
+	pr   *io.PipeReader
+	done chan struct{} // closed once the command has been waited for
+}
+
+func (r *outputReader) Read(p []byte) (int, error) { return r.pr.Read(p) }
+
+func (r *outputReader) Close() error {
+	select {
+	case <-r.done:
+	default:
+		r.c.kill(errReaderClosed)
+	}
+	r.pr.CloseWithError(errReaderClosed)
+	<-r.done
+	return nil
+}
+
+var errReaderClosed = errors.New("exec: output reader closed")
+
+// LineScanner reads the lines of a command's standard output as they are
+// written, see Cmd.Lines. Its methods follow those of bufio.Scanner.
+type LineScanner struct {
+	r   *outputReader
+	sc  *bufio.Scanner
+	err error
+}
+
+// Lines starts the command and returns a LineScanner over the lines of
+// its standard output. Once Scan returns false, Err reports the error
+// from starting or waiting for the command, or from reading its output.
+// If the caller stops scanning before then, Close must be called to stop
+// the command.
+func (c *Cmd) Lines(opts ...func(*Cmd) error) *LineScanner {
+	r, err := c.outputReader(Stdout, opts...)
+	if err != nil {
+		return &LineScanner{err: err}
+	}
+	return &LineScanner{r: r, sc: bufio.NewScanner(r)}
+}
+
+// Scan advances to the next line, which is then available from Text.
+// It returns false when there are no more lines or an error occurred.
+func (s *LineScanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	if s.sc.Scan() {
+		return true
+	}
+	s.err = s.sc.Err()
+	if s.err == nil {
+		s.err = io.EOF
+	}
+	return false
+}
+
+// Text returns the most recent line read by Scan, without its newline.
+func (s *LineScanner) Text() string { return s.sc.Text() }
+
+// Err returns the first error encountered, which is that of the command
+// if it did not exit cleanly.
+func (s *LineScanner) Err() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
+
+// Close kills the command if it is still running and waits for it to
+// exit. It is safe to call Close after scanning has finished.
+func (s *LineScanner) Close() error {
+	if s.r == nil {
+		return nil
+	}
+	if s.err == nil {
+		s.err = errReaderClosed
+	}
+	return s.r.Close()
+}
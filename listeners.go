@@ -0,0 +1,112 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Listeners passes ls to the child, for example to hand them to a new
+// version of a server which then takes over from the parent without
+// refusing connections. The listeners' file descriptors are passed from
+// fd 3 onwards, with LISTEN_FDS set to their number and LISTEN_FDNAMES to
+// their addresses, and the child uses InheritListeners to recover them.
+// The variables follow systemd's socket activation protocol, but
+// LISTEN_PID, which names the process the descriptors are meant for, is
+// unset, as the child's process ID is not known until it has started;
+// so children which use sd_listen_fds do not accept them. The parent's
+// listeners are left open. Listeners cannot be combined with other
+// ExtraFiles, and is not supported on Windows.
+func Listeners(ls ...net.Listener) func(*Cmd) error {
+	return Describe("Listeners", []Param{{"ls", ls}}, func(c *Cmd) error {
+		if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+			return notSupported("Listeners")
+		}
+		files := make([]*os.File, len(ls))
+		names := make([]string, len(ls))
+		for i, l := range ls {
+			fl, ok := l.(interface{ File() (*os.File, error) })
+			if !ok {
+				closeFiles(files)
+				return fmt.Errorf("exec: Listeners: cannot pass %T", l)
+			}
+			f, err := fl.File()
+			if err != nil {
+				closeFiles(files)
+				return err
+			}
+			files[i] = f
+			names[i] = strings.Replace(l.Addr().String(), ":", "_", -1)
+		}
+		c.finished = append(c.finished, func(*Cmd) error {
+			closeFiles(files)
+			return nil
+		})
+		c.starting = append(c.starting, func(c *Cmd) error {
+			if len(c.ExtraFiles) > 0 {
+				return errors.New("exec: Listeners cannot be combined with ExtraFiles")
+			}
+			c.ExtraFiles = files
+			return nil
+		})
+		return applyOptions(c,
+			Setenv("LISTEN_FDS", strconv.Itoa(len(ls))),
+			Setenv("LISTEN_FDNAMES", strings.Join(names, ":")),
+			Unsetenv("LISTEN_PID"),
+		)
+	})
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		if f != nil {
+			f.Close()
+		}
+	}
+}
+
+// InheritListeners returns the listeners passed to the process by
+// Listeners, or by systemd socket activation, in order. It returns no
+// listeners if none were passed. The environment variables describing
+// them are removed, so they are not passed on to the process's children.
+func InheritListeners() ([]net.Listener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+		os.Unsetenv("LISTEN_PID")
+	}()
+	if pid := os.Getenv("LISTEN_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil // meant for another process
+	}
+	s := os.Getenv("LISTEN_FDS")
+	if s == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("exec: malformed LISTEN_FDS %q", s)
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	ls := make([]net.Listener, 0, n)
+	for i := 0; i < n; i++ {
+		name := "LISTEN_FD_" + strconv.Itoa(3+i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(3+i), name)
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, l := range ls {
+				l.Close()
+			}
+			return nil, fmt.Errorf("exec: inherit listener %s: %v", name, err)
+		}
+		ls = append(ls, l)
+	}
+	return ls, nil
+}
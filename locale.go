@@ -0,0 +1,33 @@
+package exec
+
+import (
+	"errors"
+	"time"
+)
+
+// Locale runs the child in the named locale, such as "C.UTF-8", so its
+// messages and the formatting of numbers and dates are predictable.
+// LANG and LC_ALL, which overrides the other LC_ variables, are set to
+// name, and LANGUAGE, which GNU programs prefer for messages, is removed.
+func Locale(name string) func(*Cmd) error {
+	return Describe("Locale", []Param{{"name", name}}, func(c *Cmd) error {
+		if name == "" {
+			return errors.New("exec: Locale must not be empty")
+		}
+		return applyOptions(c, Setenv("LANG", name), Setenv("LC_ALL", name), Unsetenv("LANGUAGE"))
+	})
+}
+
+// Timezone runs the child in the named time zone, such as "UTC" or
+// "Europe/Paris", by setting TZ. The name must be known to time.LoadLocation.
+func Timezone(name string) func(*Cmd) error {
+	return Describe("Timezone", []Param{{"name", name}}, func(c *Cmd) error {
+		if _, err := time.LoadLocation(name); err != nil {
+			return err
+		}
+		if name == "" {
+			name = "UTC"
+		}
+		return Setenv("TZ", name)(c)
+	})
+}
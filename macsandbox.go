@@ -0,0 +1,51 @@
+package exec
+
+import (
+	"runtime"
+	"strings"
+)
+
+// Profiles for MacSandbox, in the Sandbox Profile Language. NoNetwork
+// denies network access other than by Unix domain sockets. ReadOnlyFS
+// denies writing to files other than the terminal and /dev/null; the
+// child may still write to the files and pipes it inherits, such as its
+// standard output.
+const (
+	NoNetwork = `(version 1)
+(allow default)
+(deny network*)
+(allow network* (remote unix-socket))`
+
+	ReadOnlyFS = `(version 1)
+(allow default)
+(deny file-write*)
+(allow file-write-data (literal "/dev/null") (literal "/dev/tty") (regex #"^/dev/fd/"))`
+)
+
+// MacSandbox runs the command on macOS in the sandbox described by
+// profile, with sandbox-exec. The profile is either a profile in the
+// Sandbox Profile Language, such as NoNetwork or ReadOnlyFS, or the path
+// of a file containing one. It is only supported on macOS.
+//
+//	cmd.Run(exec.MacSandbox(exec.NoNetwork))
+func MacSandbox(profile string) func(*Cmd) error {
+	return Describe("MacSandbox", []Param{{"profile", profile}}, func(c *Cmd) error {
+		if runtime.GOOS != "darwin" {
+			return notSupported("MacSandbox")
+		}
+		path, err := LookPath("sandbox-exec")
+		if err != nil {
+			return err
+		}
+		flag := "-f"
+		if strings.HasPrefix(strings.TrimSpace(profile), "(") {
+			flag = "-p"
+		}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			args := append([]string{"sandbox-exec", flag, profile, c.Path}, c.Args[1:]...)
+			c.Path, c.Args = path, args
+			return nil
+		})
+		return nil
+	})
+}
@@ -0,0 +1,131 @@
+package exec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Manifest describes a run of a command in enough detail to record its
+// provenance, see RecordManifest. Hashes are hex encoded SHA-256 digests.
+type Manifest struct {
+	Path         string        `json:"path"`           // absolute path of the program
+	PathSHA256   string        `json:"path_sha256"`    // of the program, empty if it could not be read
+	Args         []string      `json:"argv"`           // including argv[0]
+	Dir          string        `json:"dir"`            // absolute working directory
+	EnvSHA256    string        `json:"env_sha256"`     // of the sorted environment
+	Start        time.Time     `json:"start"`          // when the command was started
+	WallTime     time.Duration `json:"wall_time_ns"`   // from Start until the command exited
+	UserTime     time.Duration `json:"user_time_ns"`   // CPU time in user mode
+	SystemTime   time.Duration `json:"system_time_ns"` // CPU time in kernel mode
+	ExitCode     int           `json:"exit_code"`      // -1 if the command did not exit normally
+	StdoutSHA256 string        `json:"stdout_sha256"`
+	StdoutBytes  int64         `json:"stdout_bytes"`
+	StderrSHA256 string        `json:"stderr_sha256"`
+	StderrBytes  int64         `json:"stderr_bytes"`
+	RunID        string        `json:"run_id,omitempty"` // see Correlate
+}
+
+// RecordManifest fills in m as the command is started and once it has
+// exited. Problems reading the program to hash it are recorded in the
+// command's Warnings. If stdout and stderr share a writer, writes to it
+// are serialised so each stream can be hashed separately.
+func RecordManifest(m *Manifest) func(*Cmd) error {
+	return Describe("RecordManifest", []Param{{"m", m}}, func(c *Cmd) error {
+		stdout, stderr := &hashWriter{h: sha256.New()}, &hashWriter{h: sha256.New()}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			*m = Manifest{ExitCode: -1, Args: c.redactAll(c.Args), RunID: c.runID}
+			var err error
+			m.Path, err = filepath.Abs(filepath.Join(c.Dir, c.Path))
+			if filepath.IsAbs(c.Path) {
+				m.Path, err = c.Path, nil
+			}
+			if err != nil {
+				return err
+			}
+			if m.Dir, err = filepath.Abs(c.Dir); err != nil {
+				return err
+			}
+			if m.PathSHA256, err = fileSHA256(m.Path); err != nil {
+				c.addWarning(fmt.Errorf("exec: RecordManifest: %w", err))
+			}
+			m.EnvSHA256 = envSHA256(c.Env)
+
+			out, errw := orDiscard(c.Stdout), orDiscard(c.Stderr)
+			if interfaceEqual(c.Stdout, c.Stderr) {
+				lw := &lockedWriter{w: out}
+				out, errw = lw, lw
+			}
+			c.Stdout = io.MultiWriter(out, stdout)
+			c.Stderr = io.MultiWriter(errw, stderr)
+			m.Start = c.clk().Now()
+			return nil
+		})
+		c.finished = append(c.finished, func(c *Cmd) error {
+			if m.Start.IsZero() {
+				return nil // not started
+			}
+			m.WallTime = c.clk().Now().Sub(m.Start)
+			if ps := c.ProcessState; ps != nil {
+				m.ExitCode = ps.ExitCode()
+				m.UserTime, m.SystemTime = ps.UserTime(), ps.SystemTime()
+			}
+			m.StdoutSHA256, m.StdoutBytes = stdout.sum(), stdout.n
+			m.StderrSHA256, m.StderrBytes = stderr.sum(), stderr.n
+			return nil
+		})
+		return nil
+	})
+}
+
+// WriteTo writes m to w as indented JSON, implementing io.WriterTo.
+func (m *Manifest) WriteTo(w io.Writer) (int64, error) {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(b, '\n'))
+	return int64(n), err
+}
+
+// envSHA256 returns the SHA-256 of env, in sorted order.
+func envSHA256(env []string) string {
+	env = append([]string(nil), env...)
+	sort.Strings(env)
+	sum := sha256.Sum256([]byte(strings.Join(env, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashWriter hashes and counts the bytes written to it.
+type hashWriter struct {
+	h hash.Hash
+	n int64
+}
+
+func (hw *hashWriter) Write(p []byte) (int, error) {
+	hw.n += int64(len(p))
+	return hw.h.Write(p)
+}
+
+func (hw *hashWriter) sum() string { return hex.EncodeToString(hw.h.Sum(nil)) }
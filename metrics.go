@@ -0,0 +1,166 @@
+package exec
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DurationBuckets are the upper bounds, in seconds, of the buckets of
+// the command duration histogram exported by CommandMetrics.
+var DurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300, 900}
+
+// CommandMetrics collects metrics about the commands run with its
+// Metrics option, labelled by program name, and exports them in the
+// Prometheus text exposition format. It is an http.Handler, so it may
+// be served as a scrape target, or its output merged with that of other
+// collectors with WritePrometheus. The zero value is ready to use.
+type CommandMetrics struct {
+	mu       sync.Mutex
+	programs map[string]*programMetrics
+}
+
+type programMetrics struct {
+	started  int64
+	failed   int64 // failed to start
+	timeouts int64
+	exits    map[int]int64 // by exit code, -1 if killed by a signal
+	buckets  []int64       // counts per DurationBuckets, not cumulative
+	count    int64
+	sum      float64 // seconds
+}
+
+// Metrics records the command in m: that it started, how long it ran,
+// its exit code, and whether it was killed by IdleTimeout or CPULimit,
+// or else that it failed to start.
+func Metrics(m *CommandMetrics) func(*Cmd) error {
+	return Describe("Metrics", []Param{{"m", m}}, func(c *Cmd) error {
+		c.started = append(c.started, func(c *Cmd) error {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.program(c).started++
+			return nil
+		})
+		c.finished = append(c.finished, func(c *Cmd) error {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			p := m.program(c)
+			if c.ProcessState == nil {
+				p.failed++
+				return nil
+			}
+			code := c.ProcessState.ExitCode()
+			c.mu.Lock()
+			killed := c.killed
+			c.mu.Unlock()
+			d := c.exitTime.Sub(c.startTime).Seconds()
+
+			p.exits[code]++
+			if errors.Is(killed, ErrIdleTimeout) || errors.Is(killed, ErrCPULimit) {
+				p.timeouts++
+			}
+			p.count++
+			p.sum += d
+			for i, le := range DurationBuckets {
+				if d <= le {
+					p.buckets[i]++
+					break
+				}
+			}
+			return nil
+		})
+		return nil
+	})
+}
+
+// program returns the metrics for the command's program. m.mu must be
+// held.
+func (m *CommandMetrics) program(c *Cmd) *programMetrics {
+	name := strings.TrimSuffix(filepath.Base(c.Path), ".exe")
+	if m.programs == nil {
+		m.programs = make(map[string]*programMetrics)
+	}
+	p, ok := m.programs[name]
+	if !ok {
+		p = &programMetrics{exits: make(map[int]int64), buckets: make([]int64, len(DurationBuckets))}
+		m.programs[name] = p
+	}
+	return p
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (m *CommandMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WritePrometheus(w)
+}
+
+// WritePrometheus writes the metrics to w in the Prometheus text format:
+//
+//	exec_commands_started_total{program}
+//	exec_command_start_failures_total{program}
+//	exec_command_exits_total{program, code}
+//	exec_command_timeouts_total{program}
+//	exec_command_duration_seconds{program}, a histogram
+func (m *CommandMetrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	names := make([]string, 0, len(m.programs))
+	for name := range m.programs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b := bufio.NewWriter(w)
+	header := func(name, typ, help string) {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+	header("exec_commands_started_total", "counter", "Commands started.")
+	for _, name := range names {
+		fmt.Fprintf(b, "exec_commands_started_total{program=%s} %d\n", quoteLabel(name), m.programs[name].started)
+	}
+	header("exec_command_start_failures_total", "counter", "Commands which failed to start.")
+	for _, name := range names {
+		fmt.Fprintf(b, "exec_command_start_failures_total{program=%s} %d\n", quoteLabel(name), m.programs[name].failed)
+	}
+	header("exec_command_exits_total", "counter", "Commands exited, by exit code; -1 if killed by a signal.")
+	for _, name := range names {
+		p := m.programs[name]
+		codes := make([]int, 0, len(p.exits))
+		for code := range p.exits {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			fmt.Fprintf(b, "exec_command_exits_total{program=%s,code=\"%d\"} %d\n", quoteLabel(name), code, p.exits[code])
+		}
+	}
+	header("exec_command_timeouts_total", "counter", "Commands killed by IdleTimeout or CPULimit.")
+	for _, name := range names {
+		fmt.Fprintf(b, "exec_command_timeouts_total{program=%s} %d\n", quoteLabel(name), m.programs[name].timeouts)
+	}
+	header("exec_command_duration_seconds", "histogram", "How long commands ran.")
+	for _, name := range names {
+		p := m.programs[name]
+		var n int64
+		for i, le := range DurationBuckets {
+			n += p.buckets[i]
+			fmt.Fprintf(b, "exec_command_duration_seconds_bucket{program=%s,le=\"%s\"} %d\n", quoteLabel(name), strconv.FormatFloat(le, 'g', -1, 64), n)
+		}
+		fmt.Fprintf(b, "exec_command_duration_seconds_bucket{program=%s,le=\"+Inf\"} %d\n", quoteLabel(name), p.count)
+		fmt.Fprintf(b, "exec_command_duration_seconds_sum{program=%s} %s\n", quoteLabel(name), strconv.FormatFloat(p.sum, 'g', -1, 64))
+		fmt.Fprintf(b, "exec_command_duration_seconds_count{program=%s} %d\n", quoteLabel(name), p.count)
+	}
+	m.mu.Unlock()
+	return b.Flush()
+}
+
+// quoteLabel quotes a label value as the Prometheus text format requires.
+func quoteLabel(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
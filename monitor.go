@@ -0,0 +1,101 @@
+package exec
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ResourceSample is a measurement of the resources in use by a running
+// command, see MonitorResources.
+type ResourceSample struct {
+	Time time.Time
+	RSS  int64   // resident set size, in bytes
+	CPU  float64 // CPUs in use since the previous sample; 1 is one CPU fully busy
+	FDs  int     // open file descriptors
+}
+
+// MonitorResources samples the resources in use by the child every
+// interval while it runs. The samples are available from ResourceSamples
+// and PeakRSS. Only the child itself is measured, not its descendants.
+// It is only supported on Linux.
+func MonitorResources(interval time.Duration) func(*Cmd) error {
+	return Describe("MonitorResources", []Param{{"interval", interval}}, func(c *Cmd) error {
+		if !monitorSupported {
+			return notSupported("MonitorResources")
+		}
+		if interval <= 0 {
+			return errors.New("exec: MonitorResources interval must be positive")
+		}
+		if c.monitor != nil {
+			return errors.New("exec: MonitorResources already set")
+		}
+		m := &resourceMonitor{done: make(chan struct{}), stopped: make(chan struct{})}
+		c.monitor = m
+		c.started = append(c.started, func(c *Cmd) error {
+			go m.run(c.Process.Pid, interval)
+			c.finished = append(c.finished, func(*Cmd) error {
+				close(m.done)
+				<-m.stopped
+				return nil
+			})
+			return nil
+		})
+		return nil
+	})
+}
+
+// ResourceSamples returns the samples recorded by MonitorResources so far.
+func (c *Cmd) ResourceSamples() []ResourceSample {
+	if c.monitor == nil {
+		return nil
+	}
+	c.monitor.mu.Lock()
+	defer c.monitor.mu.Unlock()
+	return append([]ResourceSample(nil), c.monitor.samples...)
+}
+
+// PeakRSS returns the largest resident set size sampled by
+// MonitorResources so far, in bytes.
+func (c *Cmd) PeakRSS() int64 {
+	var peak int64
+	for _, s := range c.ResourceSamples() {
+		if s.RSS > peak {
+			peak = s.RSS
+		}
+	}
+	return peak
+}
+
+type resourceMonitor struct {
+	done, stopped chan struct{}
+
+	mu      sync.Mutex
+	samples []ResourceSample
+}
+
+func (m *resourceMonitor) run(pid int, interval time.Duration) {
+	defer close(m.stopped)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	var lastCPU time.Duration
+	last := time.Now()
+	for {
+		s, cpu, err := sampleProcess(pid)
+		if err == nil {
+			s.Time = time.Now()
+			if wall := s.Time.Sub(last); wall > 0 {
+				s.CPU = float64(cpu-lastCPU) / float64(wall)
+			}
+			lastCPU, last = cpu, s.Time
+			m.mu.Lock()
+			m.samples = append(m.samples, s)
+			m.mu.Unlock()
+		}
+		select {
+		case <-m.done:
+			return
+		case <-t.C:
+		}
+	}
+}
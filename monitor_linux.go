@@ -0,0 +1,40 @@
+package exec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const monitorSupported = true
+
+// clockTick is the unit of the CPU times in /proc/<pid>/stat, which is
+// 1/100th of a second on all Linux architectures Go supports.
+const clockTick = 10 * time.Millisecond
+
+// sampleProcess measures the process pid, returning the CPU time it has
+// used so far.
+func sampleProcess(pid int) (ResourceSample, time.Duration, error) {
+	var s ResourceSample
+	b, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return s, 0, err
+	}
+	// the command name, in parentheses, may contain spaces.
+	fields := strings.Fields(string(b[strings.LastIndexByte(string(b), ')')+1:]))
+	if len(fields) < 22 {
+		return s, 0, fmt.Errorf("exec: malformed /proc/%d/stat", pid)
+	}
+	// fields from the third: utime is 14th, stime 15th, rss 24th.
+	utime, _ := strconv.ParseInt(fields[11], 10, 64)
+	stime, _ := strconv.ParseInt(fields[12], 10, 64)
+	rss, _ := strconv.ParseInt(fields[21], 10, 64)
+	s.RSS = rss * int64(os.Getpagesize())
+	if fds, err := ioutil.ReadDir(fmt.Sprintf("/proc/%d/fd", pid)); err == nil {
+		s.FDs = len(fds)
+	}
+	return s, time.Duration(utime+stime) * clockTick, nil
+}
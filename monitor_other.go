@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package exec
+
+import "time"
+
+const monitorSupported = false
+
+func sampleProcess(pid int) (ResourceSample, time.Duration, error) {
+	return ResourceSample{}, 0, notSupported("MonitorResources")
+}
@@ -0,0 +1,36 @@
+package exec
+
+import "fmt"
+
+// MultiError is returned when more than one of a group of commands
+// fails, as by Graph.Run, Procfile.Wait and Supervisor.Wait. It holds
+// each failure in the order it occurred, and errors.Is and errors.As
+// match any of them.
+type MultiError []error
+
+func (m MultiError) Error() string {
+	switch len(m) {
+	case 0:
+		return "exec: no errors"
+	case 1:
+		return m[0].Error()
+	case 2:
+		return fmt.Sprintf("%v (and 1 more error)", m[0])
+	}
+	return fmt.Sprintf("%v (and %d more errors)", m[0], len(m)-1)
+}
+
+// Unwrap returns the failures.
+func (m MultiError) Unwrap() []error { return m }
+
+// joinErrors returns nil if errs is empty, its only element if it has
+// one, and a MultiError otherwise.
+func joinErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return MultiError(append([]error(nil), errs...))
+}
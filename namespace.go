@@ -0,0 +1,23 @@
+package exec
+
+// Namespace is a kind of Linux namespace in which Unshare may run the
+// child. The values are those of the corresponding CLONE_NEW flags.
+type Namespace uintptr
+
+const (
+	NewMount  Namespace = 0x00020000 // mount points
+	NewCgroup Namespace = 0x02000000 // cgroup root directory
+	NewUTS    Namespace = 0x04000000 // host and domain names
+	NewIPC    Namespace = 0x08000000 // System V IPC and POSIX message queues
+	NewUser   Namespace = 0x10000000 // user and group IDs
+	NewPID    Namespace = 0x20000000 // process IDs
+	NewNet    Namespace = 0x40000000 // network devices, addresses and ports
+)
+
+// IDMap maps a range of user or group IDs in a user namespace to IDs
+// outside it, see MapUsers and MapGroups.
+type IDMap struct {
+	Inside  int // first ID in the namespace
+	Outside int // first ID outside the namespace
+	Size    int // number of IDs mapped
+}
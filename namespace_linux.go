@@ -0,0 +1,97 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// Unshare runs the child in new namespaces of the kinds ns, isolating
+// it from the rest of the system without a container runtime: with
+// NewNet it has only a loopback device, which is down, and with NewPID
+// it is process 1 and sees only its descendants.
+//
+// Creating namespaces other than a user namespace requires
+// CAP_SYS_ADMIN, unless NewUser is among ns, in which case an
+// unprivileged caller may create them if the host allows, see
+// Capabilities. If the user and group IDs in a new user namespace are
+// not mapped with MapUsers and MapGroups, the caller's IDs are mapped
+// to root in the namespace, and setgroups is disabled.
+//
+// Mounts made by the child in a new mount namespace propagate to the
+// host if the mount points are shared, as they are by default under
+// systemd, so a child which mounts file systems should first make its
+// mount points private with "mount --make-rprivate /".
+func Unshare(ns ...Namespace) func(*Cmd) error {
+	return Describe("Unshare", []Param{{"ns", ns}}, func(c *Cmd) error {
+		if c.SysProcAttr == nil {
+			c.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		for _, n := range ns {
+			c.SysProcAttr.Cloneflags |= uintptr(n)
+		}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			attr := c.SysProcAttr
+			if attr.Cloneflags&uintptr(NewUser) == 0 {
+				return nil
+			}
+			if len(attr.UidMappings) == 0 {
+				attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}}
+			}
+			if len(attr.GidMappings) == 0 {
+				attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}}
+			}
+			return nil
+		})
+		return nil
+	})
+}
+
+// MapUsers maps user IDs in the user namespace created by
+// Unshare(NewUser) to IDs outside it. Mapping IDs other than the
+// caller's own requires CAP_SETUID, or the newuidmap tool.
+func MapUsers(maps ...IDMap) func(*Cmd) error {
+	return Describe("MapUsers", []Param{{"maps", maps}}, func(c *Cmd) error {
+		if c.SysProcAttr == nil {
+			c.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		c.starting = append(c.starting, needUserNamespace("MapUsers"))
+		for _, m := range maps {
+			c.SysProcAttr.UidMappings = append(c.SysProcAttr.UidMappings, syscall.SysProcIDMap{ContainerID: m.Inside, HostID: m.Outside, Size: m.Size})
+		}
+		return nil
+	})
+}
+
+// MapGroups maps group IDs in the user namespace created by
+// Unshare(NewUser) to IDs outside it, as MapUsers does user IDs.
+// Mapping IDs other than the caller's own group enables setgroups in
+// the namespace, which requires CAP_SETGID.
+func MapGroups(maps ...IDMap) func(*Cmd) error {
+	return Describe("MapGroups", []Param{{"maps", maps}}, func(c *Cmd) error {
+		if c.SysProcAttr == nil {
+			c.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		c.starting = append(c.starting, needUserNamespace("MapGroups"))
+		for _, m := range maps {
+			c.SysProcAttr.GidMappings = append(c.SysProcAttr.GidMappings, syscall.SysProcIDMap{ContainerID: m.Inside, HostID: m.Outside, Size: m.Size})
+			if m.Outside != os.Getgid() || m.Size != 1 {
+				c.SysProcAttr.GidMappingsEnableSetgroups = true
+			}
+		}
+		return nil
+	})
+}
+
+// needUserNamespace returns a starting hook which checks that the child
+// will run in a new user namespace, as the ID mappings set by the named
+// option require. Without one, the child would wait forever for them to
+// be written.
+func needUserNamespace(option string) func(*Cmd) error {
+	return func(c *Cmd) error {
+		if (c.SysProcAttr.Cloneflags|c.SysProcAttr.Unshareflags)&uintptr(NewUser) == 0 {
+			return fmt.Errorf("exec: %s needs Unshare(NewUser)", option)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,28 @@
+//go:build !linux
+// +build !linux
+
+package exec
+
+// Unshare runs the child in new namespaces of the kinds ns. It is only
+// supported on Linux.
+func Unshare(ns ...Namespace) func(*Cmd) error {
+	return Describe("Unshare", []Param{{"ns", ns}}, func(*Cmd) error {
+		return notSupported("Unshare")
+	})
+}
+
+// MapUsers maps user IDs in the user namespace created by
+// Unshare(NewUser). It is only supported on Linux.
+func MapUsers(maps ...IDMap) func(*Cmd) error {
+	return Describe("MapUsers", []Param{{"maps", maps}}, func(*Cmd) error {
+		return notSupported("MapUsers")
+	})
+}
+
+// MapGroups maps group IDs in the user namespace created by
+// Unshare(NewUser). It is only supported on Linux.
+func MapGroups(maps ...IDMap) func(*Cmd) error {
+	return Describe("MapGroups", []Param{{"maps", maps}}, func(*Cmd) error {
+		return notSupported("MapGroups")
+	})
+}
@@ -0,0 +1,260 @@
+package exec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// netClassSeq allocates tc minor class ids for NetRateLimit.
+var netClassSeq uint32
+
+// netClassAttempts bounds the class ids addNetClass tries.
+const netClassAttempts = 16
+
+// netShaping records, by interface, the commands in this process using
+// the shared htb qdisc, and whether this process installed it.
+var netShaping struct {
+	sync.Mutex
+	devs map[string]*shapedDev
+}
+
+type shapedDev struct {
+	users     int
+	installed bool
+}
+
+// NetRateLimit limits the egress bandwidth of the child to bytesPerSec.
+//
+// The child is placed in a net_cls cgroup whose traffic, both IPv4 and
+// IPv6, is matched by a tc htb class on the interface carrying the
+// default route. This requires the net_cls cgroup controller to be
+// mounted, the tc binary on PATH, and sufficient privilege to modify
+// both. The child is started from a thread moved into the cgroup, so it
+// is limited from its first instruction. The htb root qdisc and cgroup
+// filter are shared between commands; the per command class and cgroup
+// are removed once the command has exited, and the qdisc once no class
+// remains under it, if it was installed by this process.
+func NetRateLimit(bytesPerSec int64) func(*Cmd) error {
+	return Describe("NetRateLimit", []Param{{"bytesPerSec", bytesPerSec}}, func(c *Cmd) error {
+		if bytesPerSec <= 0 {
+			return errors.New("exec: NetRateLimit must be positive")
+		}
+		root, err := cgroupMount("net_cls")
+		if err != nil {
+			return err
+		}
+		dev, err := defaultRouteDevice()
+		if err != nil {
+			return err
+		}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			if err := acquireShaping(dev); err != nil {
+				return err
+			}
+			minor, err := addNetClass(dev, bytesPerSec)
+			if err != nil {
+				releaseShaping(dev)
+				return err
+			}
+			c.finished = append(c.finished, func(*Cmd) error {
+				err := tc("class", "del", "dev", dev, "classid", fmt.Sprintf("1:%x", minor))
+				if errRelease := releaseShaping(dev); err == nil {
+					err = errRelease
+				}
+				return err
+			})
+			dir := filepath.Join(root, fmt.Sprintf("pkg-exec-%d-%d", os.Getpid(), minor))
+			if err := os.Mkdir(dir, 0755); err != nil {
+				return err
+			}
+			c.finished = append(c.finished, func(*Cmd) error {
+				return os.Remove(dir)
+			})
+			if err := writeFile(filepath.Join(dir, "net_cls.classid"), strconv.Itoa(1<<16|int(minor))); err != nil {
+				return err
+			}
+			spawn := c.spawn
+			c.spawn = func(start func() error) error {
+				if spawn != nil {
+					return spawn(func() error { return spawnInCgroup(root, dir, start) })
+				}
+				return spawnInCgroup(root, dir, start)
+			}
+			return nil
+		})
+		return nil
+	})
+}
+
+// addNetClass adds an htb class limiting traffic to bytesPerSec under
+// the shared qdisc on dev, returning its minor id. Other processes may
+// add classes to the qdisc too, so ids are allocated from a base derived
+// from the process ID, and the next is tried if one is taken.
+func addNetClass(dev string, bytesPerSec int64) (uint32, error) {
+	base := uint32(os.Getpid()) * netClassAttempts
+	for i := 1; ; i++ {
+		minor := (base+atomic.AddUint32(&netClassSeq, 1))%0xfff0 + 0x10
+		err := tc("class", "add", "dev", dev, "parent", "1:", "classid", fmt.Sprintf("1:%x", minor), "htb", "rate", fmt.Sprintf("%dbps", bytesPerSec))
+		if err == nil || i == netClassAttempts || !strings.Contains(err.Error(), "File exists") {
+			return minor, err
+		}
+	}
+}
+
+// spawnInCgroup calls start from a thread moved into the net_cls cgroup
+// dir, so that the child it creates is born there, and then moves the
+// thread back. If the thread cannot be moved back it is left locked, so
+// that the Go runtime ends it rather than reusing it.
+func spawnInCgroup(root, dir string, start func() error) error {
+	runtime.LockOSThread()
+	tid := strconv.Itoa(syscall.Gettid())
+	home, err := threadCgroup(tid, "net_cls")
+	if err != nil {
+		runtime.UnlockOSThread()
+		return err
+	}
+	if err := writeFile(filepath.Join(dir, "tasks"), tid); err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("exec: NetRateLimit: %v", err)
+	}
+	err = start()
+	if writeFile(filepath.Join(root, home, "tasks"), tid) == nil {
+		runtime.UnlockOSThread()
+	}
+	return err
+}
+
+// threadCgroup returns the path, relative to the root of its hierarchy,
+// of the cgroup v1 group of controller containing the thread tid.
+func threadCgroup(tid, controller string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join("/proc/self/task", tid, "cgroup"))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		// 5:net_cls,net_prio:/
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) == 3 && contains(strings.Split(fields[1], ","), controller) {
+			return fields[2], nil
+		}
+	}
+	return "", fmt.Errorf("exec: %s cgroup of thread %s not found", controller, tid)
+}
+
+// acquireShaping installs the shared htb root qdisc and cgroup classifier
+// on dev, if they are not already present, for use by a command.
+func acquireShaping(dev string) error {
+	netShaping.Lock()
+	defer netShaping.Unlock()
+	if netShaping.devs == nil {
+		netShaping.devs = make(map[string]*shapedDev)
+	}
+	d := netShaping.devs[dev]
+	if d == nil {
+		d = &shapedDev{}
+		netShaping.devs[dev] = d
+	}
+	if d.users == 0 {
+		installed, err := tcSetup(dev)
+		if err != nil {
+			return err
+		}
+		d.installed = installed
+	}
+	d.users++
+	return nil
+}
+
+// releaseShaping records that a command has finished with the qdisc on
+// dev, removing it if this process installed it, no command in this
+// process uses it, and no class, such as one of another process, remains
+// under it.
+func releaseShaping(dev string) error {
+	netShaping.Lock()
+	defer netShaping.Unlock()
+	d := netShaping.devs[dev]
+	d.users--
+	if d.users > 0 || !d.installed {
+		return nil
+	}
+	out, err := tcOutput("class", "show", "dev", dev, "parent", "1:")
+	if err != nil || strings.TrimSpace(out) != "" {
+		return err
+	}
+	d.installed = false
+	return tc("qdisc", "del", "dev", dev, "root", "handle", "1:", "htb")
+}
+
+// tcSetup installs the shared htb root qdisc and cgroup classifier on dev,
+// if they are not already present, reporting whether it did.
+func tcSetup(dev string) (bool, error) {
+	out, err := tcOutput("qdisc", "show", "dev", dev)
+	if err != nil {
+		return false, err
+	}
+	if strings.Contains(out, "qdisc htb 1: root") {
+		return false, nil
+	}
+	if err := tc("qdisc", "add", "dev", dev, "root", "handle", "1:", "htb"); err != nil {
+		// another process may have installed it since.
+		if out, errShow := tcOutput("qdisc", "show", "dev", dev); errShow == nil && strings.Contains(out, "qdisc htb 1: root") {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := tc("filter", "add", "dev", dev, "parent", "1:", "protocol", "all", "prio", "10", "handle", "1:", "cgroup"); err != nil {
+		tc("qdisc", "del", "dev", dev, "root", "handle", "1:", "htb")
+		if strings.Contains(err.Error(), "classifier not found") {
+			return false, fmt.Errorf("exec: NetRateLimit: cgroup classifier %w: %v", ErrNotSupported, err)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func tc(args ...string) error {
+	_, err := tcOutput(args...)
+	return err
+}
+
+// tcOutput runs tc with args, returning its output.
+func tcOutput(args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := exec.Command("tc", args...)
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := runChild(cmd); err != nil {
+		return "", fmt.Errorf("exec: tc %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(out.String()))
+	}
+	return out.String(), nil
+}
+
+// defaultRouteDevice returns the name of the interface carrying the
+// IPv4 default route.
+func defaultRouteDevice() (string, error) {
+	b, err := ioutil.ReadFile("/proc/net/route")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(b), "\n")[1:] {
+		fields := strings.Fields(line)
+		if len(fields) > 1 && fields[1] == "00000000" {
+			return fields[0], nil
+		}
+	}
+	return "", errors.New("exec: no default route")
+}
+
+func writeFile(path, data string) error {
+	return ioutil.WriteFile(path, []byte(data), 0644)
+}
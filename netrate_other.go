@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package exec
+
+// NetRateLimit limits the egress bandwidth of the child to bytesPerSec.
+// It is only supported on Linux.
+func NetRateLimit(bytesPerSec int64) func(*Cmd) error {
+	return Describe("NetRateLimit", []Param{{"bytesPerSec", bytesPerSec}}, func(*Cmd) error {
+		return notSupported("NetRateLimit")
+	})
+}
@@ -0,0 +1,95 @@
+package exec
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// NormalizeNewlines converts the line endings in the child's output to
+// \n before it reaches the writers connected to stdout and stderr. Both
+// \r\n, as written by Windows tools, and a lone \r, as used to redraw
+// progress lines, become \n.
+func NormalizeNewlines() func(*Cmd) error {
+	return Describe("NormalizeNewlines", nil, func(c *Cmd) error {
+		var nws []*newlineWriter
+		c.starting = append(c.starting, func(c *Cmd) error {
+			wrapOutput(c, func(w io.Writer) io.Writer {
+				nw := &newlineWriter{w: w}
+				nws = append(nws, nw)
+				return nw
+			})
+			return nil
+		})
+		c.finished = append(c.finished, func(*Cmd) error {
+			var err error
+			for _, nw := range nws {
+				if errFlush := nw.flush(); err == nil {
+					err = errFlush
+				}
+			}
+			return err
+		})
+		return nil
+	})
+}
+
+// newlineWriter replaces \r\n and \r with \n in the bytes written to it.
+type newlineWriter struct {
+	w io.Writer
+
+	mu  sync.Mutex
+	cr  bool // the last byte written was \r, which has not been passed on
+	buf []byte
+}
+
+func (nw *newlineWriter) Write(p []byte) (int, error) {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+	n := len(p)
+	buf := nw.buf[:0]
+	if nw.cr {
+		buf = append(buf, '\n')
+		if len(p) > 0 && p[0] == '\n' {
+			p = p[1:]
+		}
+		nw.cr = false
+	}
+	for {
+		i := bytes.IndexByte(p, '\r')
+		if i < 0 {
+			break
+		}
+		buf = append(buf, p[:i]...)
+		p = p[i+1:]
+		if len(p) == 0 {
+			// wait to see if \n follows.
+			nw.cr = true
+			break
+		}
+		buf = append(buf, '\n')
+		if p[0] == '\n' {
+			p = p[1:]
+		}
+	}
+	buf = append(buf, p...)
+	nw.buf = buf
+	if len(buf) > 0 {
+		if _, err := nw.w.Write(buf); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// flush writes the newline for a final \r.
+func (nw *newlineWriter) flush() error {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+	if !nw.cr {
+		return nil
+	}
+	nw.cr = false
+	_, err := nw.w.Write([]byte{'\n'})
+	return err
+}
@@ -0,0 +1,28 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// OOMScoreAdj adjusts the badness score the kernel uses to choose which
+// process to kill when memory is exhausted, from -1000, never kill, to
+// 1000, kill first. A positive score makes an expendable child a more
+// likely victim than its parent. The score is set as soon as the child
+// has started; lowering it requires CAP_SYS_RESOURCE.
+func OOMScoreAdj(score int) func(*Cmd) error {
+	return Describe("OOMScoreAdj", []Param{{"score", score}}, func(c *Cmd) error {
+		if score < -1000 || score > 1000 {
+			return errors.New("exec: OOMScoreAdj must be between -1000 and 1000")
+		}
+		c.started = append(c.started, func(c *Cmd) error {
+			path := fmt.Sprintf("/proc/%d/oom_score_adj", c.Process.Pid)
+			if err := writeFile(path, strconv.Itoa(score)); err != nil {
+				return fmt.Errorf("exec: OOMScoreAdj: %v", err)
+			}
+			return nil
+		})
+		return nil
+	})
+}
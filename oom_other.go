@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package exec
+
+// OOMScoreAdj adjusts the badness score the kernel uses to choose which
+// process to kill when memory is exhausted. It is only supported on Linux.
+func OOMScoreAdj(score int) func(*Cmd) error {
+	return Describe("OOMScoreAdj", []Param{{"score", score}}, func(*Cmd) error {
+		return notSupported("OOMScoreAdj")
+	})
+}
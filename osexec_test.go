@@ -19,6 +19,7 @@ import (
 	"net/http/httptest"
 	"os"
 	osexec "os/exec"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"strconv"
@@ -761,6 +762,87 @@ func TestHelperProcess(*testing.T) {
 		}
 		fmt.Printf("%s", string(output))
 		os.Exit(0)
+	case "writetmp":
+		n, _ := strconv.Atoi(args[0])
+		f, err := ioutil.TempFile("", "")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "TempFile: %v\n", err)
+			os.Exit(1)
+		}
+		if _, err := f.Write(make([]byte, n)); err != nil {
+			fmt.Fprintf(os.Stderr, "Write: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "sleep":
+		d, _ := time.ParseDuration(args[0])
+		time.Sleep(d)
+		os.Exit(0)
+	case "writefile": // text, path
+		if err := ioutil.WriteFile(args[1], []byte(args[0]), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "WriteFile: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "plugin": // serves echo over tcp
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Listen: %v\n", err)
+			os.Exit(1)
+		}
+		if err := exec.PluginHandshake(1, l); err != nil {
+			fmt.Fprintf(os.Stderr, "PluginHandshake: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("serving")
+		conn, err := l.Accept()
+		if err != nil {
+			os.Exit(1)
+		}
+		io.Copy(conn, conn)
+		os.Exit(0)
+	case "replace": // dir, key, value; replaced by getenvwd
+		err := exec.Replace(os.Args[0], []string{"-test.run=TestHelperProcess", "--", "getenvwd", args[1]}, nil,
+			exec.Dir(args[0]), exec.Setenv(args[1], args[2]))
+		fmt.Fprintf(os.Stderr, "Replace: %v\n", err)
+		os.Exit(1)
+	case "getenvwd": // key
+		wd, _ := os.Getwd()
+		fmt.Println(wd, os.Getenv(args[0]))
+		os.Exit(0)
+	case "argv0":
+		fmt.Println(os.Args[0])
+		os.Exit(0)
+	case "inheritlistener": // serves echo on the first inherited listener
+		ls, err := exec.InheritListeners()
+		if err != nil || len(ls) == 0 {
+			fmt.Fprintf(os.Stderr, "InheritListeners: %v, %d listeners\n", err, len(ls))
+			os.Exit(1)
+		}
+		conn, err := ls[0].Accept()
+		if err != nil {
+			os.Exit(1)
+		}
+		io.Copy(conn, conn)
+		os.Exit(0)
+	case "spin":
+		d, _ := time.ParseDuration(args[0])
+		for end := time.Now().Add(d); time.Now().Before(end); {
+		}
+		os.Exit(0)
+	case "tick":
+		n, _ := strconv.Atoi(args[0])
+		d, _ := time.ParseDuration(args[1])
+		for i := 0; i < n; i++ {
+			fmt.Println(i)
+			time.Sleep(d)
+		}
+		os.Exit(0)
+	case "ignoresig":
+		signal.Ignore(os.Interrupt)
+		fmt.Println("ready")
+		time.Sleep(10 * time.Second)
+		os.Exit(0)
 	case "lookpath":
 		p, err := exec.LookPath(args[0])
 		if err != nil {
@@ -769,6 +851,34 @@ func TestHelperProcess(*testing.T) {
 		}
 		fmt.Print(p)
 		os.Exit(0)
+	case "netratelimit": // runs sleep with NetRateLimit
+		if err := exec.Command("sleep", "1").Run(exec.NetRateLimit(1 << 20)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "servespec": // -exec-spec, spec
+		exec.ServeSpec(args)
+		fmt.Fprintln(os.Stderr, "not started by RemoteSpec")
+		os.Exit(2)
+	case "reaper": // prints the number of children left after orphaning one
+		if err := exec.Reaper(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err := exec.Command("/bin/sh", "-c", "(sleep 0.1 &)").Run(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		time.Sleep(500 * time.Millisecond)
+		n := 0
+		files, _ := filepath.Glob("/proc/self/task/*/children")
+		for _, file := range files {
+			b, _ := ioutil.ReadFile(file)
+			n += len(strings.Fields(string(b)))
+		}
+		fmt.Println(n)
+		os.Exit(0)
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command %q\n", cmd)
 		os.Exit(2)
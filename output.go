@@ -0,0 +1,131 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// ErrOutputLimit is returned by Wait when the command was killed by
+// MaxOutputStrict.
+var ErrOutputLimit = errors.New("exec: output limit exceeded")
+
+// ErrOutputTruncated is recorded, wrapped, in the Warnings of a command
+// whose output was truncated by MaxOutput.
+var ErrOutputTruncated = errors.New("exec: output truncated")
+
+// MaxOutput limits the output passed to the writers connected to the
+// child's stdout and stderr to n bytes each; anything beyond that is
+// discarded. If stdout and stderr share a writer the limit applies to
+// their combined output. The child is not otherwise affected, but the
+// truncation is recorded in the command's Warnings.
+func MaxOutput(n int64) func(*Cmd) error {
+	return Describe("MaxOutput", []Param{{"n", n}}, maxOutput("MaxOutput", n, false))
+}
+
+// MaxOutputStrict is like MaxOutput, but kills the command once it
+// exceeds the limit, causing Wait to return ErrOutputLimit.
+func MaxOutputStrict(n int64) func(*Cmd) error {
+	return Describe("MaxOutputStrict", []Param{{"n", n}}, maxOutput("MaxOutputStrict", n, true))
+}
+
+func maxOutput(name string, n int64, strict bool) func(*Cmd) error {
+	return func(c *Cmd) error {
+		if n < 0 {
+			return fmt.Errorf("exec: %s must not be negative", name)
+		}
+		var lws []*limitWriter
+		c.starting = append(c.starting, func(c *Cmd) error {
+			wrapOutput(c, func(w io.Writer) io.Writer {
+				lw := &limitWriter{w: w, n: n}
+				if strict {
+					lw.exceeded = func() { c.kill(ErrOutputLimit) }
+				}
+				lws = append(lws, lw)
+				return lw
+			})
+			return nil
+		})
+		c.finished = append(c.finished, func(c *Cmd) error {
+			for _, lw := range lws {
+				if lw.truncated() > 0 {
+					c.addWarning(fmt.Errorf("%w to %d bytes, %d bytes discarded", ErrOutputTruncated, n, lw.truncated()))
+				}
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
+// limitWriter passes the first n bytes written to w, discarding the rest.
+type limitWriter struct {
+	w        io.Writer
+	exceeded func() // called once when the limit is first exceeded
+
+	mu        sync.Mutex
+	n         int64
+	discarded int64
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if int64(len(p)) <= l.n {
+		l.n -= int64(len(p))
+		return l.w.Write(p)
+	}
+	if l.discarded == 0 && l.exceeded != nil {
+		l.exceeded()
+	}
+	l.discarded += int64(len(p)) - l.n
+	if l.n > 0 {
+		_, err := l.w.Write(p[:l.n])
+		l.n = 0
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (l *limitWriter) truncated() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.discarded
+}
+
+// wrapOutput replaces the writers connected to the child's stdout and
+// stderr with the result of fn. A nil writer is replaced by ioutil.Discard
+// before being passed to fn. If stdout and stderr share a writer, fn is
+// called once and the result is shared, preserving the serialisation
+// os/exec provides for that case.
+func wrapOutput(c *Cmd, fn func(io.Writer) io.Writer) {
+	if interfaceEqual(c.Stdout, c.Stderr) {
+		w := fn(orDiscard(c.Stdout))
+		c.Stdout, c.Stderr = w, w
+		return
+	}
+	c.Stdout = fn(orDiscard(c.Stdout))
+	c.Stderr = fn(orDiscard(c.Stderr))
+}
+
+func orDiscard(w io.Writer) io.Writer {
+	if w == nil {
+		return ioutil.Discard
+	}
+	return w
+}
+
+// interfaceEqual protects against panics from doing equality tests on
+// two interfaces with non-comparable underlying types.
+func interfaceEqual(a, b interface{}) (eq bool) {
+	defer func() {
+		if recover() != nil {
+			eq = false
+		}
+	}()
+	return a == b
+}
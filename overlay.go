@@ -0,0 +1,8 @@
+package exec
+
+// UpperDir returns the directory in which the changes the command made
+// to its root file system are recorded, if it was run with OverlayRoot,
+// and otherwise "".
+func (c *Cmd) UpperDir() string {
+	return c.upperDir
+}
@@ -0,0 +1,121 @@
+package exec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// overlayEnv is the environment variable in which OverlayRoot passes
+// its directories to the sandbox shim.
+const overlayEnv = "EXEC_OVERLAY_ROOT"
+
+// overlayDirs are the directories passed to the sandbox shim.
+type overlayDirs struct {
+	Lower string `json:"lower"`
+	Work  string `json:"work"`
+}
+
+// OverlayRoot runs the child in a new mount namespace with an overlay
+// file system over lowerDir, "/" if it is empty, as its root directory,
+// so that it may appear to install or modify files anywhere without
+// changing them. The changes are recorded in the upper directory of the
+// overlay, workDir/upper, see UpperDir, from which they may be
+// inspected or copied. /dev, /proc and /sys are bound into the new root
+// directory, and the child starts in its working directory within it.
+//
+// workDir is created if need be, and may be reused to build up changes
+// over several commands. The kernel does not allow it to be on the same
+// file system as lowerDir if lowerDir contains it, so when lowerDir is
+// "/" it must be on another, such as a tmpfs. The program's path must
+// exist within lowerDir, as it does when lowerDir is "/".
+//
+// The overlay is mounted by the sandbox shim, so the main function must
+// begin by calling Reexec. A caller other than root runs the child in a
+// new user namespace, see Unshare.
+//
+//	cmd := exec.Command("make", "install")
+//	err := cmd.Run(exec.OverlayRoot("/", "/dev/shm/install"))
+//	// the installed files are in cmd.UpperDir()
+func OverlayRoot(lowerDir, workDir string) func(*Cmd) error {
+	return Describe("OverlayRoot", []Param{{"lowerDir", lowerDir}, {"workDir", workDir}}, func(c *Cmd) error {
+		if lowerDir == "" {
+			lowerDir = "/"
+		}
+		var dirs overlayDirs
+		var err error
+		if dirs.Lower, err = filepath.Abs(lowerDir); err != nil {
+			return err
+		}
+		if dirs.Work, err = filepath.Abs(workDir); err != nil {
+			return err
+		}
+		if strings.ContainsAny(dirs.Lower+dirs.Work, ",:") {
+			return errors.New("exec: OverlayRoot: paths may not contain ',' or ':'")
+		}
+		c.upperDir = filepath.Join(dirs.Work, "upper")
+		ns := []Namespace{NewMount}
+		if os.Geteuid() != 0 {
+			ns = append(ns, NewUser)
+		}
+		if err := applyOptions(c, Unshare(ns...)); err != nil {
+			return err
+		}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			for _, dir := range []string{"upper", "work", "merged"} {
+				if err := os.MkdirAll(filepath.Join(dirs.Work, dir), 0755); err != nil {
+					return err
+				}
+			}
+			b, err := json.Marshal(dirs)
+			if err != nil {
+				return err
+			}
+			return applyOptions(c, Setenv(overlayEnv, string(b)))
+		})
+		return c.sandbox()
+	})
+}
+
+// mountOverlay mounts the overlay encoded by OverlayRoot and changes
+// the root directory to it. The process must be in a new mount
+// namespace.
+func mountOverlay(encoded string) error {
+	var dirs overlayDirs
+	if err := json.Unmarshal([]byte(encoded), &dirs); err != nil {
+		return err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	// stop the mounts below propagating to the parent's namespace.
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("making mounts private: %v", err)
+	}
+	merged := filepath.Join(dirs.Work, "merged")
+	opts := fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s", dirs.Lower, filepath.Join(dirs.Work, "upper"), filepath.Join(dirs.Work, "work"))
+	if err := syscall.Mount("overlay", merged, "overlay", 0, opts); err != nil {
+		return fmt.Errorf("mounting overlay: %v", err)
+	}
+	for _, dir := range []string{"/dev", "/proc", "/sys"} {
+		target := filepath.Join(merged, dir)
+		if fi, err := os.Stat(target); err != nil || !fi.IsDir() {
+			continue
+		}
+		if err := syscall.Mount(dir, target, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+			return fmt.Errorf("binding %s: %v", dir, err)
+		}
+	}
+	if err := syscall.Chroot(merged); err != nil {
+		return err
+	}
+	if err := os.Chdir(cwd); err != nil {
+		return os.Chdir("/")
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package exec
+
+// OverlayRoot runs the child with an overlay over lowerDir as its root
+// file system. It is only supported on Linux.
+func OverlayRoot(lowerDir, workDir string) func(*Cmd) error {
+	return Describe("OverlayRoot", []Param{{"lowerDir", lowerDir}, {"workDir", workDir}}, func(*Cmd) error {
+		return notSupported("OverlayRoot")
+	})
+}
@@ -0,0 +1,47 @@
+package exec
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is returned by Start, Run or Wait when an option, a hook
+// such as BeforeFunc or AfterFunc, or a callback passed to an option,
+// such as that of Diagnostics, WarningFunc or Askpass, panics. If the
+// child had started it is killed, and it is always waited for, so a
+// panicking callback does not leave it running.
+type PanicError struct {
+	Value interface{} // the value passed to panic
+	Stack []byte      // the stack of the panicking goroutine
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("exec: panic: %v", e.Value)
+}
+
+// Unwrap returns the value passed to panic, if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// callHook calls fn, returning a panic as a *PanicError.
+func callHook(c *Cmd, fn func(*Cmd) error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return fn(c)
+}
+
+// guard calls fn, a callback run while the command is configured or
+// running, killing the command with a *PanicError if it panics.
+func (c *Cmd) guard(fn func()) {
+	defer func() {
+		if v := recover(); v != nil {
+			c.kill(&PanicError{Value: v, Stack: debug.Stack()})
+		}
+	}()
+	fn()
+}
@@ -0,0 +1,77 @@
+//go:build linux || freebsd
+// +build linux freebsd
+
+package exec
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"syscall"
+)
+
+// Pdeathsig sets the signal the child is sent when its parent exits,
+// see SysProcAttr.Pdeathsig. On Linux the parent is the thread which
+// started the child; KillOnParentDeath accounts for that.
+func Pdeathsig(sig os.Signal) func(*Cmd) error {
+	return Describe("Pdeathsig", []Param{{"sig", sig}}, func(c *Cmd) error {
+		return setPdeathsig(c, "Pdeathsig", sig)
+	})
+}
+
+// KillOnParentDeath sends the child sig, such as SIGTERM or SIGKILL,
+// if the parent exits, even if it crashes or is killed, so that helpers
+// are not orphaned. The signal is also sent if the parent has already
+// exited by the time the child starts. It is not sent if the child
+// executes a setuid program or changes its credentials.
+//
+// Linux sends the signal when the thread which started the child exits,
+// rather than the process, and the Go runtime ends threads locked by
+// goroutines which exit. So the child is started from a thread which is
+// never ended, serialising the start of commands using
+// KillOnParentDeath.
+func KillOnParentDeath(sig os.Signal) func(*Cmd) error {
+	return Describe("KillOnParentDeath", []Param{{"sig", sig}}, func(c *Cmd) error {
+		if err := setPdeathsig(c, "KillOnParentDeath", sig); err != nil {
+			return err
+		}
+		if runtime.GOOS == "linux" {
+			c.spawn = spawnLocked
+		}
+		return nil
+	})
+}
+
+func setPdeathsig(c *Cmd, option string, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("exec: %s: unsupported signal %v", option, sig)
+	}
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.SysProcAttr.Pdeathsig = s
+	return nil
+}
+
+var spawner struct {
+	once sync.Once
+	reqs chan func()
+}
+
+// spawnLocked calls start on a thread which is never ended.
+func spawnLocked(start func() error) error {
+	spawner.once.Do(func() {
+		spawner.reqs = make(chan func())
+		go func() {
+			runtime.LockOSThread()
+			for f := range spawner.reqs {
+				f()
+			}
+		}()
+	})
+	done := make(chan error, 1)
+	spawner.reqs <- func() { done <- start() }
+	return <-done
+}
@@ -0,0 +1,22 @@
+//go:build !linux && !freebsd
+// +build !linux,!freebsd
+
+package exec
+
+import "os"
+
+// Pdeathsig sets the signal the child is sent when its parent exits. It
+// is only supported on Linux and FreeBSD.
+func Pdeathsig(sig os.Signal) func(*Cmd) error {
+	return Describe("Pdeathsig", []Param{{"sig", sig}}, func(*Cmd) error {
+		return notSupported("Pdeathsig")
+	})
+}
+
+// KillOnParentDeath sends the child sig if the parent exits. It is only
+// supported on Linux and FreeBSD.
+func KillOnParentDeath(sig os.Signal) func(*Cmd) error {
+	return Describe("KillOnParentDeath", []Param{{"sig", sig}}, func(*Cmd) error {
+		return notSupported("KillOnParentDeath")
+	})
+}
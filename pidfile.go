@@ -0,0 +1,66 @@
+package exec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PIDFile writes the child's process ID to path once it has started,
+// and removes the file once it has exited. To manage a daemon started
+// with Detach, write its process ID with WritePIDFile instead.
+func PIDFile(path string) func(*Cmd) error {
+	return Describe("PIDFile", []Param{{"path", path}}, func(c *Cmd) error {
+		c.started = append(c.started, func(c *Cmd) error {
+			return WritePIDFile(path, c.Process.Pid)
+		})
+		c.finished = append(c.finished, func(*Cmd) error {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			return nil
+		})
+		return nil
+	})
+}
+
+// WritePIDFile writes pid to path, replacing it atomically so readers
+// never see a partially written file.
+func WritePIDFile(path string, pid int) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+"-")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(f, pid)
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// ReadPIDFile returns the process ID written to path by PIDFile or
+// WritePIDFile. Use IsRunning to check whether the process is still
+// running; the file may be stale if the process was killed.
+func ReadPIDFile(path string) (int, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("exec: bad process ID in %s", path)
+	}
+	return pid, nil
+}
@@ -0,0 +1,15 @@
+//go:build plan9
+// +build plan9
+
+package exec
+
+import (
+	"os"
+	"strconv"
+)
+
+// IsRunning reports whether a process with the given ID is running.
+func IsRunning(pid int) bool {
+	_, err := os.Stat("/proc/" + strconv.Itoa(pid))
+	return err == nil
+}
@@ -0,0 +1,14 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package exec
+
+import "syscall"
+
+// IsRunning reports whether a process with the given ID is running,
+// including processes owned by other users. A process which has exited
+// but has not been waited for by its parent is reported as running.
+func IsRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
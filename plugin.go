@@ -0,0 +1,130 @@
+package exec
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PluginVersionEnv is the environment variable in which StartPlugin
+// passes the protocol version it expects to the plugin.
+const PluginVersionEnv = "EXEC_PLUGIN_VERSION"
+
+// ErrPluginHandshake is returned, wrapped, by StartPlugin when the plugin
+// does not complete the handshake.
+var ErrPluginHandshake = errors.New("exec: plugin handshake failed")
+
+// Plugin is a child process serving a protocol over a network
+// connection, started by StartPlugin.
+type Plugin struct {
+	Cmd     *Cmd
+	Network string // as passed to net.Dial
+	Addr    string
+	Conn    net.Conn // connected to Addr
+}
+
+// StartPlugin starts cmd as a plugin speaking protocol version, and
+// connects to it. The plugin must call PluginHandshake, or write the
+// line "version|network|address" as the first line of its stdout, within
+// timeout. The rest of its stdout is passed to any writer set by Stdout.
+func StartPlugin(cmd *Cmd, version int, timeout time.Duration, opts ...func(*Cmd) error) (*Plugin, error) {
+	handshake := make(chan string, 1)
+	opts = append(opts, Setenv(PluginVersionEnv, strconv.Itoa(version)), func(c *Cmd) error {
+		c.starting = append(c.starting, func(c *Cmd) error {
+			pr, pw, err := os.Pipe()
+			if err != nil {
+				return err
+			}
+			w := orDiscard(c.Stdout)
+			c.Stdout = pw
+			copied := make(chan struct{})
+			c.started = append(c.started, func(*Cmd) error {
+				pw.Close()
+				go func() {
+					defer close(copied)
+					defer pr.Close()
+					br := bufio.NewReader(pr)
+					line, err := br.ReadString('\n')
+					if err != nil {
+						close(handshake) // the plugin exited
+						return
+					}
+					handshake <- strings.TrimRight(line, "\r\n")
+					io.Copy(w, br)
+				}()
+				return nil
+			})
+			c.finished = append(c.finished, func(*Cmd) error {
+				pw.Close()
+				<-copied
+				return nil
+			})
+			return nil
+		})
+		return nil
+	})
+	if err := cmd.Start(opts...); err != nil {
+		return nil, err
+	}
+	fail := func(err error) (*Plugin, error) {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	var line string
+	select {
+	case l, ok := <-handshake:
+		if !ok {
+			return fail(fmt.Errorf("%w: plugin exited", ErrPluginHandshake))
+		}
+		line = l
+	case <-time.After(timeout):
+		return fail(fmt.Errorf("%w: no handshake after %v", ErrPluginHandshake, timeout))
+	}
+	fields := strings.Split(line, "|")
+	if len(fields) != 3 {
+		return fail(fmt.Errorf("%w: malformed handshake %q", ErrPluginHandshake, line))
+	}
+	if fields[0] != strconv.Itoa(version) {
+		return fail(fmt.Errorf("%w: plugin speaks version %s, want %d", ErrPluginHandshake, fields[0], version))
+	}
+	conn, err := net.DialTimeout(fields[1], fields[2], timeout)
+	if err != nil {
+		return fail(err)
+	}
+	return &Plugin{Cmd: cmd, Network: fields[1], Addr: fields[2], Conn: conn}, nil
+}
+
+// Close closes the connection to the plugin, kills it, and waits for it
+// to exit.
+func (p *Plugin) Close() error {
+	p.Conn.Close()
+	p.Cmd.Process.Kill()
+	err := p.Cmd.Wait()
+	if err != nil && p.Cmd.ProcessState != nil {
+		err = nil // killed, as expected
+	}
+	return err
+}
+
+// PluginHandshake is called by a plugin started by StartPlugin once it
+// is listening on l. It reports an error if the plugin was not started by
+// StartPlugin, or StartPlugin expects a protocol version other than
+// version.
+func PluginHandshake(version int, l net.Listener) error {
+	want := os.Getenv(PluginVersionEnv)
+	if want == "" {
+		return errors.New("exec: not started as a plugin")
+	}
+	if want != strconv.Itoa(version) {
+		return fmt.Errorf("exec: plugin speaks version %d, want %s", version, want)
+	}
+	_, err := fmt.Fprintf(os.Stdout, "%d|%s|%s\n", version, l.Addr().Network(), l.Addr())
+	return err
+}
@@ -0,0 +1,38 @@
+package exec_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/exec"
+)
+
+func TestStartPlugin(t *testing.T) {
+	var out strings.Builder
+	p, err := exec.StartPlugin(helperCommand(t, "plugin"), 1, 10*time.Second, exec.Stdout(&out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprint(p.Conn, "ping")
+	b := make([]byte, 4)
+	if _, err := io.ReadFull(p.Conn, b); err != nil || string(b) != "ping" {
+		t.Errorf("want ping echoed by plugin, got %q, %v", b, err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "serving\n" {
+		t.Errorf("want output after handshake passed through, got %q", out.String())
+	}
+}
+
+func TestStartPluginVersionMismatch(t *testing.T) {
+	_, err := exec.StartPlugin(helperCommand(t, "plugin"), 2, 10*time.Second)
+	if !errors.Is(err, exec.ErrPluginHandshake) {
+		t.Fatalf("want ErrPluginHandshake, got %v", err)
+	}
+}
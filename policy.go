@@ -0,0 +1,128 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Policy decides whether a command may be started, returning an error
+// if it may not. It is passed the absolute path of the program, its
+// arguments, including argv[0], and its environment.
+type Policy func(path string, args, env []string) error
+
+// ErrDenied is wrapped by the errors of the Policies returned by
+// AllowDirs and DenyCommand.
+var ErrDenied = errors.New("denied by policy")
+
+var policy struct {
+	sync.Mutex
+	p Policy
+}
+
+// SetPolicy sets the policy checked before every command is started,
+// replacing any set before. If p is nil, commands are not checked.
+//
+// The policy is checked once all options and hooks have been applied,
+// so it sees the command as it will be run: an option which runs the
+// command under another program, such as Elevated, must be allowed to
+// run that program. If the policy returns an error, the command is not
+// started and Start returns the error.
+//
+//	exec.SetPolicy(exec.Policies(
+//		exec.AllowDirs("/usr/bin", "/usr/local/tools"),
+//		exec.DenyCommand("curl"),
+//	))
+func SetPolicy(p Policy) {
+	policy.Lock()
+	defer policy.Unlock()
+	policy.p = p
+}
+
+// checkPolicy checks the command against the policy set by SetPolicy.
+func (c *Cmd) checkPolicy() error {
+	policy.Lock()
+	p := policy.p
+	policy.Unlock()
+	if p == nil {
+		return nil
+	}
+	if c.Err != nil {
+		return c.Err
+	}
+	path, err := filepath.Abs(filepath.Join(c.Dir, c.Path))
+	if filepath.IsAbs(c.Path) {
+		path, err = c.Path, nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := p(path, c.Args, c.Env); err != nil {
+		return fmt.Errorf("exec: %s: %w", path, err)
+	}
+	return nil
+}
+
+// Policies returns a Policy which allows a command only if every one of
+// ps does.
+func Policies(ps ...Policy) Policy {
+	return func(path string, args, env []string) error {
+		for _, p := range ps {
+			if err := p(path, args, env); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// AllowDirs returns a Policy which allows only programs in dirs or
+// their subdirectories. Symbolic links in the program's path are
+// followed, so a link in an allowed directory to a program elsewhere is
+// denied.
+func AllowDirs(dirs ...string) Policy {
+	return func(path string, args, env []string) error {
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			path = real
+		}
+		for _, dir := range dirs {
+			if real, err := filepath.EvalSymlinks(dir); err == nil {
+				dir = real
+			}
+			if rel, err := filepath.Rel(dir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: not in an allowed directory", ErrDenied)
+	}
+}
+
+// DenyCommand returns a Policy which denies running the program name,
+// matched by its base name with any .exe extension removed, with all of
+// args among its arguments, in any order. With no args the program is
+// never allowed. Arguments are compared word for word, so "-rf" does
+// not match "-fr" or "-r -f", and a program reached through a shell,
+// env or a link of another name is not matched: DenyCommand guards
+// against mistakes, not against a determined caller.
+func DenyCommand(name string, args ...string) Policy {
+	return func(path string, argv, env []string) error {
+		if strings.TrimSuffix(filepath.Base(path), ".exe") != name {
+			return nil
+		}
+		have := make(map[string]bool)
+		if len(argv) > 0 {
+			for _, a := range argv[1:] {
+				have[a] = true
+			}
+		}
+		for _, a := range args {
+			if !have[a] {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrDenied, strings.Join(append([]string{name}, args...), " "))
+	}
+}
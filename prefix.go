@@ -0,0 +1,133 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// PrefixOutput writes prefix at the start of each line the child writes
+// to stdout and stderr. Lines are passed to the underlying writers whole,
+// so the output of several commands sharing a writer remains legible.
+// A line longer than 64KiB, such as progress output without newlines,
+// is written in parts of that length as it arrives, each on a line of
+// its own.
+func PrefixOutput(prefix string) func(*Cmd) error {
+	return decorateLines("PrefixOutput", []Param{{"prefix", prefix}}, func(*Cmd) func() string {
+		return func() string { return prefix }
+	})
+}
+
+// PrefixOutputPID is like PrefixOutput, but prefixes each line with the
+// base name of the command and its process ID, as in "server[1234]: ".
+func PrefixOutputPID() func(*Cmd) error {
+	return decorateLines("PrefixOutputPID", nil, func(c *Cmd) func() string {
+		var once sync.Once
+		var prefix string
+		return func() string {
+			once.Do(func() {
+				prefix = fmt.Sprintf("%s[%d]: ", filepath.Base(c.Path), c.Process.Pid)
+			})
+			return prefix
+		}
+	})
+}
+
+// TimestampOutput writes the time at the start of each line the child
+// writes to stdout and stderr, formatted with layout and followed by a
+// space. The time is that at which the end of the line was written.
+// If layout is empty, time.RFC3339 is used.
+func TimestampOutput(layout string) func(*Cmd) error {
+	params := []Param{{"layout", layout}}
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return decorateLines("TimestampOutput", params, func(c *Cmd) func() string {
+		clk := c.clk()
+		return func() string { return clk.Now().Format(layout) + " " }
+	})
+}
+
+// decorateLines returns the named option, which wraps the child's stdout
+// and stderr in lineWriters whose prefix function is returned by fn once
+// the command is starting.
+func decorateLines(name string, params []Param, fn func(*Cmd) func() string) func(*Cmd) error {
+	return Describe(name, params, func(c *Cmd) error {
+		var lws []*lineWriter
+		c.starting = append(c.starting, func(c *Cmd) error {
+			prefix := fn(c)
+			wrapOutput(c, func(w io.Writer) io.Writer {
+				lw := &lineWriter{w: w, prefix: prefix}
+				lws = append(lws, lw)
+				return lw
+			})
+			return nil
+		})
+		c.finished = append(c.finished, func(*Cmd) error {
+			var err error
+			for _, lw := range lws {
+				if errFlush := lw.flush(); err == nil {
+					err = errFlush
+				}
+			}
+			return err
+		})
+		return nil
+	})
+}
+
+// maxPrefixedLine is the length at which lineWriter breaks a line.
+const maxPrefixedLine = 64 << 10
+
+// lineWriter writes each complete line written to it to w in a single
+// Write, preceded by the result of prefix. Lines longer than
+// maxPrefixedLine are broken, so that they are not held in memory.
+type lineWriter struct {
+	w      io.Writer
+	prefix func() string
+
+	mu      sync.Mutex
+	partial []byte // unterminated final line
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := len(p)
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			break
+		}
+		line := append(append([]byte(l.prefix()), l.partial...), p[:i+1]...)
+		l.partial = l.partial[:0]
+		p = p[i+1:]
+		if _, err := l.w.Write(line); err != nil {
+			return n - len(p), err
+		}
+	}
+	l.partial = append(l.partial, p...)
+	for len(l.partial) >= maxPrefixedLine {
+		line := append(append([]byte(l.prefix()), l.partial[:maxPrefixedLine]...), '\n')
+		l.partial = append(l.partial[:0], l.partial[maxPrefixedLine:]...)
+		if _, err := l.w.Write(line); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// flush writes any unterminated final line.
+func (l *lineWriter) flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.partial) == 0 {
+		return nil
+	}
+	_, err := l.w.Write(append([]byte(l.prefix()), l.partial...))
+	l.partial = nil
+	return err
+}
@@ -0,0 +1,13 @@
+package exec
+
+// PriorityClass is a Windows process priority class, see Priority.
+type PriorityClass uint32
+
+// Priority classes, from lowest to highest.
+const (
+	IdlePriority        PriorityClass = 0x00000040
+	BelowNormalPriority PriorityClass = 0x00004000
+	NormalPriority      PriorityClass = 0x00000020
+	AboveNormalPriority PriorityClass = 0x00008000
+	HighPriority        PriorityClass = 0x00000080
+)
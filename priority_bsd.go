@@ -0,0 +1,20 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+// +build darwin dragonfly freebsd netbsd openbsd
+
+package exec
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// setNice sets the nice level of the child once it has started, as
+// PRIO_PROCESS applies to the whole process here.
+func setNice(c *Cmd, level int) {
+	c.started = append(c.started, func(c *Cmd) error {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, c.Process.Pid, level); err != nil {
+			return fmt.Errorf("exec: Nice: %v", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,70 @@
+package exec
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+)
+
+const (
+	ioprioWhoProcess = 1
+	ioprioClassBE    = 2
+	ioprioClassShift = 13
+)
+
+// setNice starts the child from a thread at the nice level and the
+// corresponding I/O priority, so that the child inherits them from its
+// first instruction; on Linux PRIO_PROCESS applies to a single thread.
+func setNice(c *Cmd, level int) {
+	spawn := c.spawn
+	c.spawn = func(start func() error) error {
+		if spawn != nil {
+			return spawn(func() error { return spawnNice(level, start) })
+		}
+		// run on a thread of its own, which ends if it cannot be
+		// restored rather than holding the caller's goroutine.
+		done := make(chan error, 1)
+		go func() { done <- spawnNice(level, start) }()
+		return <-done
+	}
+}
+
+// spawnNice calls start from the current thread set to the nice level
+// and the best-effort I/O priority ionice derives from it, and then
+// restores the thread. Raising the priority again usually requires
+// privilege, so if the thread cannot be restored it is left locked,
+// so that the Go runtime ends it rather than reusing it.
+func spawnNice(level int, start func() error) error {
+	runtime.LockOSThread()
+	// the raw syscall returns 20 - nice.
+	prio, err := syscall.Getpriority(syscall.PRIO_PROCESS, 0)
+	if err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("exec: Nice: %v", err)
+	}
+	ioprio, _, errno := syscall.RawSyscall(syscall.SYS_IOPRIO_GET, ioprioWhoProcess, 0, 0)
+	if errno != 0 {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("exec: Nice: %v", errno)
+	}
+	restore := func() bool {
+		_, _, errno := syscall.RawSyscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, 0, ioprio)
+		return syscall.Setpriority(syscall.PRIO_PROCESS, 0, 20-prio) == nil && errno == 0
+	}
+	err = syscall.Setpriority(syscall.PRIO_PROCESS, 0, level)
+	if err == nil {
+		be := ioprioClassBE<<ioprioClassShift | (level+20)/5
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, 0, uintptr(be)); errno != 0 {
+			err = errno
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("exec: Nice: %v", err)
+	} else {
+		err = start()
+	}
+	if restore() {
+		runtime.UnlockOSThread()
+	}
+	return err
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!windows
+
+package exec
+
+// Nice runs the child at the given nice level.
+// It is not supported on this platform.
+func Nice(level int) func(*Cmd) error {
+	return Describe("Nice", []Param{{"level", level}}, func(*Cmd) error {
+		return notSupported("Nice")
+	})
+}
+
+// Priority runs the child in the given priority class.
+// It is only supported on Windows.
+func Priority(class PriorityClass) func(*Cmd) error {
+	return Describe("Priority", []Param{{"class", class}}, func(*Cmd) error {
+		return notSupported("Priority")
+	})
+}
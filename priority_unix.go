@@ -0,0 +1,31 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package exec
+
+import "errors"
+
+// Nice runs the child at the given nice level, from -20, the highest
+// priority, to 19, the lowest; lowering it below that of the parent
+// requires privilege. On Linux the child is started at that level, from
+// a thread set to it, and its I/O priority is set in the best-effort
+// class to the level ionice derives from the nice level. Elsewhere the
+// level is set once the child has started, so threads or children it
+// creates before then keep the parent's level.
+func Nice(level int) func(*Cmd) error {
+	return Describe("Nice", []Param{{"level", level}}, func(c *Cmd) error {
+		if level < -20 || level > 19 {
+			return errors.New("exec: Nice level must be between -20 and 19")
+		}
+		setNice(c, level)
+		return nil
+	})
+}
+
+// Priority runs the child in the given priority class.
+// It is only supported on Windows.
+func Priority(class PriorityClass) func(*Cmd) error {
+	return Describe("Priority", []Param{{"class", class}}, func(*Cmd) error {
+		return notSupported("Priority")
+	})
+}
@@ -0,0 +1,23 @@
+package exec
+
+import "syscall"
+
+// Nice runs the child at the given nice level.
+// It is not supported on Windows, see Priority.
+func Nice(level int) func(*Cmd) error {
+	return Describe("Nice", []Param{{"level", level}}, func(*Cmd) error {
+		return notSupported("Nice")
+	})
+}
+
+// Priority runs the child in the given priority class, for example
+// BelowNormalPriority for CPU heavy background work.
+func Priority(class PriorityClass) func(*Cmd) error {
+	return Describe("Priority", []Param{{"class", class}}, func(c *Cmd) error {
+		if c.SysProcAttr == nil {
+			c.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		c.SysProcAttr.CreationFlags |= uint32(class)
+		return nil
+	})
+}
@@ -0,0 +1,37 @@
+package exec
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ProbeTCP returns a readiness probe, for ProcfileEntry.Ready, which
+// succeeds once addr accepts TCP connections.
+func ProbeTCP(addr string) func() error {
+	return func() error {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// ProbeHTTP returns a readiness probe, for ProcfileEntry.Ready, which
+// succeeds once a GET of url returns a 2xx or 3xx status.
+func ProbeHTTP(url string) func() error {
+	client := &http.Client{Timeout: time.Second}
+	return func() error {
+		resp, err := client.Get(url)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("exec: %s: %s", url, resp.Status)
+		}
+		return nil
+	}
+}
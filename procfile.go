@@ -0,0 +1,638 @@
+package exec
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProcfileEntry is a process type declared in a Procfile.
+type ProcfileEntry struct {
+	Name    string
+	Command string // run by the shell
+
+	// DependsOn names the entries which must be ready before the
+	// processes of this entry are started.
+	DependsOn []string
+
+	// Ready, if non nil, is polled once the processes of this entry have
+	// started, until it returns nil, before starting the entries which
+	// depend on it. Entries without a Ready probe are ready once started.
+	Ready func() error
+
+	// StopGrace, if non zero, overrides Procfile.StopGrace for the
+	// processes of this entry.
+	StopGrace time.Duration
+}
+
+// StopOrder controls the order in which a Procfile stops its processes.
+type StopOrder int
+
+const (
+	StopInParallel StopOrder = iota // stop every process at once
+	StopInReverse                   // stop each entry once the entries which depend on it have exited
+)
+
+// ProcessExit records how a process run by a Procfile exited.
+type ProcessExit struct {
+	Name     string
+	Err      error // the error returned by the last run of the process
+	Stopped  bool  // the process was stopped, rather than exiting by itself
+	Graceful bool  // the stopped process exited within its grace period
+}
+
+// Clean reports whether the process exited cleanly, either by itself
+// without error or, if it was stopped, within its grace period.
+func (e ProcessExit) Clean() bool {
+	if e.Stopped {
+		return e.Graceful
+	}
+	return e.Err == nil
+}
+
+// LoadProcfile reads the Procfile at path, see ParseProcfile.
+func LoadProcfile(path string) ([]ProcfileEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	entries, err := ParseProcfile(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// ParseProcfile reads the entries of a Procfile, which has one process
+// type per line in the form "name: command". Blank lines and lines
+// starting with # are ignored.
+func ParseProcfile(r io.Reader) ([]ProcfileEntry, error) {
+	var entries []ProcfileEntry
+	seen := make(map[string]bool)
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			return nil, fmt.Errorf("exec: line %d: want name: command", n)
+		}
+		e := ProcfileEntry{
+			Name:    strings.TrimSpace(line[:i]),
+			Command: strings.TrimSpace(line[i+1:]),
+		}
+		if !validProcName(e.Name) {
+			return nil, fmt.Errorf("exec: line %d: invalid process name %q", n, e.Name)
+		}
+		if e.Command == "" {
+			return nil, fmt.Errorf("exec: line %d: process %q has no command", n, e.Name)
+		}
+		if seen[e.Name] {
+			return nil, fmt.Errorf("exec: line %d: duplicate process %q", n, e.Name)
+		}
+		seen[e.Name] = true
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+func validProcName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ParseScale parses a list of scale counts in the form used by foreman,
+// "web=2,worker=1", for Procfile.Scale.
+func ParseScale(s string) (map[string]int, error) {
+	scale := make(map[string]int)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		name, count, ok := strings.Cut(f, "=")
+		n, err := strconv.Atoi(count)
+		if !ok || !validProcName(name) || err != nil || n < 0 {
+			return nil, fmt.Errorf("exec: invalid scale %q", f)
+		}
+		scale[name] = n
+	}
+	return scale, nil
+}
+
+// LoadEnvFile reads the .env file at path, see ParseEnv.
+func LoadEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	env, err := ParseEnv(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return env, nil
+}
+
+// ParseEnv reads environment variables from a .env file, returning them
+// in the form "key=value" for Procfile.Env. Each line has the form
+// KEY=value, optionally preceded by "export". Values may be single
+// quoted, taken literally, or double quoted, in which case \n and other
+// escapes are interpreted. Blank lines and lines starting with # are
+// ignored.
+func ParseEnv(r io.Reader) ([]string, error) {
+	return parseEnv(r, nil)
+}
+
+// parseEnv parses a .env file as ParseEnv does. If lookup is not nil,
+// references to variables, as $KEY or ${KEY}, in values which are not
+// single quoted are expanded to those set earlier in the file, or else
+// to those returned by lookup.
+func parseEnv(r io.Reader, lookup func(key string) string) ([]string, error) {
+	var env []string
+	expand := func(key string) string {
+		prefix := key + "="
+		for i := len(env) - 1; i >= 0; i-- {
+			if strings.HasPrefix(env[i], prefix) {
+				return env[i][len(prefix):]
+			}
+		}
+		return lookup(key)
+	}
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, val, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("exec: line %d: want KEY=value", n)
+		}
+		val = strings.TrimSpace(val)
+		literal := false
+		switch {
+		case len(val) >= 2 && val[0] == '\'' && val[len(val)-1] == '\'':
+			val, literal = val[1:len(val)-1], true
+		case len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"':
+			v, err := strconv.Unquote(val)
+			if err != nil {
+				return nil, fmt.Errorf("exec: line %d: invalid quoted value %s", n, val)
+			}
+			val = v
+		default:
+			val = strings.TrimSpace(stripComment(val))
+		}
+		if lookup != nil && !literal {
+			val = os.Expand(val, expand)
+		}
+		env = append(env, key+"="+val)
+	}
+	return env, sc.Err()
+}
+
+// Procfile runs the processes declared in a Procfile together, in the
+// manner of foreman. Each entry may be scaled to run several instances,
+// named after the entry and numbered from 1, as in "web.1". Their output
+// is multiplexed onto a single writer, each line prefixed with the name
+// of its process. Each process is kept running by a Supervisor; when
+// one exits and is not restarted, the others are stopped. A Procfile
+// must not be copied after first use.
+type Procfile struct {
+	Entries []ProcfileEntry
+
+	// Output receives the output of the processes.
+	// The default is os.Stdout.
+	Output io.Writer
+
+	// Color colors the prefix of each process with ANSI escape codes.
+	Color bool
+
+	// Renderer, if non nil, displays the output of the processes in
+	// place of Output and Color.
+	Renderer Renderer
+
+	// Scale is the number of instances of each entry to run, by name.
+	// Entries not in Scale run one instance.
+	Scale map[string]int
+
+	// Env holds environment variables, in the form "key=value", set for
+	// every process in addition to those of the current process. Each
+	// instance also has PORT set, see BasePort, and PS set to its name.
+	Env []string
+
+	// BasePort is the PORT of the first instance of the first entry.
+	// Each entry is assigned a block of 100 ports from BasePort, one per
+	// instance, so the second instance of the third entry has PORT
+	// BasePort+201. The default is 5000.
+	BasePort int
+
+	// Restart and MaxRestarts control whether each process is restarted
+	// when it exits, see Supervisor. The default is RestartAlways with
+	// no limit.
+	Restart     RestartPolicy
+	MaxRestarts int
+
+	// StopSignal and StopGrace control how each process is shut down,
+	// see Cmd.Stop. The defaults are os.Interrupt and 10s.
+	StopSignal os.Signal
+	StopGrace  time.Duration
+
+	// StopOrder controls the order in which the processes are stopped.
+	// The default is StopInParallel.
+	StopOrder StopOrder
+
+	// ReadyTimeout bounds how long Start waits for an entry with a Ready
+	// probe to become ready. The default is one minute.
+	ReadyTimeout time.Duration
+
+	mu       sync.Mutex
+	procs    []procfileProc // in the order they were started
+	deps     [][]int        // indexes of the entries each entry depends on
+	stopping bool
+	errs     []error
+	wg       sync.WaitGroup // supervisors which have not exited
+	done     chan struct{}
+}
+
+// Run starts the processes and waits for them to exit, see Wait.
+func (p *Procfile) Run() error {
+	if err := p.Start(); err != nil {
+		return err
+	}
+	return p.Wait()
+}
+
+// Start starts the processes. Entries are started in dependency order,
+// the processes of an entry being started once every entry it depends on
+// is ready, so Start may block for up to ReadyTimeout per entry. If a
+// process cannot be started or an entry does not become ready, those
+// already started are stopped and the error is returned.
+func (p *Procfile) Start() error {
+	p.mu.Lock()
+	if p.done != nil {
+		p.mu.Unlock()
+		return errors.New("exec: Procfile already started")
+	}
+	p.done = make(chan struct{})
+	p.mu.Unlock()
+	err := p.start()
+	go func() {
+		p.wg.Wait()
+		close(p.done)
+	}()
+	if err == errStoppedStarting {
+		// a process exited, or Stop was called, before the rest were
+		// started. Stop any started since, and leave Wait to report why.
+		p.stopProcs()
+		return nil
+	}
+	if err != nil {
+		p.mu.Lock()
+		p.stopping = true
+		p.mu.Unlock()
+		p.stopProcs()
+		<-p.done
+	}
+	return err
+}
+
+// procfileProc is a process started by a Procfile.
+type procfileProc struct {
+	name  string
+	entry int // index in Entries
+	s     *Supervisor
+}
+
+func (p *Procfile) start() error {
+	for name, n := range p.Scale {
+		if n < 0 {
+			return fmt.Errorf("exec: Procfile scale of %q must not be negative", name)
+		}
+	}
+	order, deps, err := p.startOrder()
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.deps = deps
+	p.mu.Unlock()
+
+	r := p.Renderer
+	if r == nil {
+		out := p.Output
+		if out == nil {
+			out = os.Stdout
+		}
+		r = PrefixRenderer(out, p.Color)
+	}
+	base := p.BasePort
+	if base == 0 {
+		base = 5000
+	}
+	type proc struct {
+		name, cmd string
+		port      int
+		out       io.Writer
+	}
+	procs := make([][]proc, len(p.Entries))
+	var names []string
+	for i, e := range p.Entries {
+		n, ok := p.Scale[e.Name]
+		if !ok {
+			n = 1
+		}
+		for j := 0; j < n; j++ {
+			name := fmt.Sprintf("%s.%d", e.Name, j+1)
+			procs[i] = append(procs[i], proc{name: name, cmd: e.Command, port: base + 100*i + j})
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return errors.New("exec: Procfile has no processes to run")
+	}
+	ws := r.Writers(names)
+	if len(ws) != len(names) {
+		return fmt.Errorf("exec: Renderer returned %d writers for %d processes", len(ws), len(names))
+	}
+	for i := range procs {
+		for j := range procs[i] {
+			procs[i][j].out, ws = ws[0], ws[1:]
+		}
+	}
+
+	for _, i := range order {
+		e := p.Entries[i]
+		for _, pr := range procs[i] {
+			if p.isStopping() {
+				return errStoppedStarting
+			}
+			cmd := pr.cmd
+			if runtime.GOOS != "windows" {
+				// as foreman does, so signals reach the process itself
+				// rather than the shell.
+				cmd = "exec " + cmd
+			}
+			spec := shellSpec(cmd)
+			// an empty prefix passes the renderer whole lines.
+			spec.Opts = []func(*Cmd) error{Stdout(pr.out), Stderr(pr.out), PrefixOutput("")}
+			for _, kv := range p.Env {
+				k, v, _ := strings.Cut(kv, "=")
+				spec.Opts = append(spec.Opts, Setenv(k, v))
+			}
+			spec.Opts = append(spec.Opts, Setenv("PORT", strconv.Itoa(pr.port)), Setenv("PS", pr.name))
+			s := &Supervisor{
+				Spec:        spec,
+				MaxRestarts: p.MaxRestarts,
+				Restart:     p.Restart,
+				StopSignal:  p.StopSignal,
+				StopGrace:   p.StopGrace,
+			}
+			name := pr.name
+			s.OnStateChange = func(state State, err error) { r.StateChanged(name, state, err) }
+			if e.StopGrace != 0 {
+				s.StopGrace = e.StopGrace
+			}
+			if err := s.Start(); err != nil {
+				return fmt.Errorf("exec: %s: %w", pr.name, err)
+			}
+			p.wg.Add(1)
+			go func(name string) {
+				defer p.wg.Done()
+				p.exited(name, s.Wait())
+			}(pr.name)
+			p.mu.Lock()
+			p.procs = append(p.procs, procfileProc{name: pr.name, entry: i, s: s})
+			stopping := p.stopping
+			p.mu.Unlock()
+			if stopping {
+				// s may have been missed by stopProcs.
+				return errStoppedStarting
+			}
+		}
+		if e.Ready != nil && len(procs[i]) > 0 {
+			if err := p.waitReady(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var errStoppedStarting = errors.New("exec: Procfile stopped while starting")
+
+// startOrder returns the indexes of the entries in the order they should
+// be started, which is the order they were declared, except that each
+// entry follows those it depends on. The indexes of the entries each
+// entry depends on are also returned.
+func (p *Procfile) startOrder() ([]int, [][]int, error) {
+	g := Graph{Tasks: make([]Task, len(p.Entries))}
+	for i, e := range p.Entries {
+		g.Tasks[i] = Task{Name: e.Name, Deps: e.DependsOn}
+	}
+	deps, err := g.resolve()
+	if err != nil {
+		return nil, nil, err
+	}
+	started := make([]bool, len(deps))
+	var order []int
+	for len(order) < len(deps) {
+	next:
+		for i, ds := range deps {
+			if started[i] {
+				continue
+			}
+			for _, d := range ds {
+				if !started[d] {
+					continue next
+				}
+			}
+			started[i] = true
+			order = append(order, i)
+		}
+	}
+	return order, deps, nil
+}
+
+// waitReady polls e.Ready until it succeeds, the ReadyTimeout expires,
+// or the Procfile is stopped.
+func (p *Procfile) waitReady(e ProcfileEntry) error {
+	timeout := p.ReadyTimeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		err := e.Ready()
+		if err == nil {
+			return nil
+		}
+		if p.isStopping() {
+			return fmt.Errorf("exec: %s: stopped before ready: %w", e.Name, err)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("exec: %s: not ready after %v: %w", e.Name, timeout, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func (p *Procfile) isStopping() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stopping
+}
+
+// exited is called when the supervisor of process name exits, stopping
+// the other processes if this is the first to do so.
+func (p *Procfile) exited(name string, err error) {
+	p.mu.Lock()
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("exec: %s: %w", name, err))
+	}
+	if p.stopping {
+		p.mu.Unlock()
+		return
+	}
+	p.stopping = true
+	p.mu.Unlock()
+	go p.stopProcs()
+}
+
+// Stop stops the processes and waits for them to exit.
+func (p *Procfile) Stop() error {
+	p.mu.Lock()
+	if p.done == nil {
+		p.mu.Unlock()
+		return errors.New("exec: Procfile not started")
+	}
+	p.stopping = true
+	p.mu.Unlock()
+	p.stopProcs()
+	<-p.done
+	return nil
+}
+
+// Wait waits for the processes to exit. If they were stopped because a
+// process exited with an error, that error is returned, or a MultiError
+// if others also exited with an error before they could be stopped.
+func (p *Procfile) Wait() error {
+	p.mu.Lock()
+	done := p.done
+	p.mu.Unlock()
+	if done == nil {
+		return errors.New("exec: Procfile not started")
+	}
+	<-done
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return joinErrors(p.errs)
+}
+
+// Exits reports how each process exited, in the order they were started.
+// It should be called once Wait has returned.
+func (p *Procfile) Exits() []ProcessExit {
+	p.mu.Lock()
+	procs := p.procs
+	p.mu.Unlock()
+	exits := make([]ProcessExit, len(procs))
+	for i, pr := range procs {
+		pr.s.mu.Lock()
+		exits[i] = ProcessExit{
+			Name:     pr.name,
+			Err:      pr.s.err,
+			Stopped:  pr.s.state == Stopped,
+			Graceful: pr.s.graceful,
+		}
+		pr.s.mu.Unlock()
+	}
+	return exits
+}
+
+// stopProcs stops the processes which have been started, in the order
+// given by StopOrder.
+func (p *Procfile) stopProcs() {
+	p.mu.Lock()
+	procs, deps := p.procs, p.deps
+	p.mu.Unlock()
+	byEntry := make([][]*Supervisor, len(deps))
+	var all []*Supervisor
+	for _, pr := range procs {
+		byEntry[pr.entry] = append(byEntry[pr.entry], pr.s)
+		all = append(all, pr.s)
+	}
+	if p.StopOrder != StopInReverse {
+		stopAll(all)
+		return
+	}
+	dependents := make([][]int, len(deps))
+	for i, ds := range deps {
+		for _, d := range ds {
+			dependents[d] = append(dependents[d], i)
+		}
+	}
+	stopped := make([]chan struct{}, len(deps))
+	for i := range stopped {
+		stopped[i] = make(chan struct{})
+	}
+	var wg sync.WaitGroup
+	for i := range deps {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer close(stopped[i])
+			for _, j := range dependents[i] {
+				<-stopped[j]
+			}
+			stopAll(byEntry[i])
+		}(i)
+	}
+	wg.Wait()
+}
+
+// stopAll stops the supervisors in parallel, so their grace periods
+// overlap.
+func stopAll(sups []*Supervisor) {
+	var wg sync.WaitGroup
+	for _, s := range sups {
+		wg.Add(1)
+		go func(s *Supervisor) {
+			defer wg.Done()
+			s.Stop()
+		}(s)
+	}
+	wg.Wait()
+}
+
+// lockedWriter serialises writes to w.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
@@ -0,0 +1,241 @@
+package exec_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/exec"
+)
+
+// helperShell returns a shell command line which runs the helper process.
+func helperShell(t *testing.T, s ...string) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping test; helper is run by /bin/sh")
+	}
+	return fmt.Sprintf("env GO_WANT_HELPER_PROCESS=1 %s -test.run=TestHelperProcess -- %s", os.Args[0], strings.Join(s, " "))
+}
+
+func TestParseProcfile(t *testing.T) {
+	entries, err := exec.ParseProcfile(strings.NewReader("# dev\nweb: bin/web -p $PORT\n\nworker: bin/worker\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []exec.ProcfileEntry{{Name: "web", Command: "bin/web -p $PORT"}, {Name: "worker", Command: "bin/worker"}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("want %v, got %v", want, entries)
+	}
+	if _, err := exec.ParseProcfile(strings.NewReader("web: a\nweb: b\n")); err == nil {
+		t.Error("want error for duplicate process")
+	}
+}
+
+func TestProcfile(t *testing.T) {
+	var out bytes.Buffer
+	p := &exec.Procfile{
+		Entries: []exec.ProcfileEntry{
+			{Name: "once", Command: helperShell(t, "echo", "hello")},
+			{Name: "sleeper", Command: helperShell(t, "sleep", "10s")},
+		},
+		Output:  &out,
+		Restart: exec.RestartNever,
+	}
+	if err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "once.1    | hello\n"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestProcfileScale(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping test; command is run by /bin/sh")
+	}
+	// processes are stopped once the first exits, so each waits, for a
+	// while, until all three have written their line.
+	dir := t.TempDir()
+	command := `sh -c 'echo $PS $PORT $GREETING; touch "$DONE/$PS"
+i=0; while [ $(ls "$DONE" | wc -l) -lt 3 ] && [ $i -lt 500 ]; do sleep 0.01; i=$((i+1)); done'`
+	var out bytes.Buffer
+	p := &exec.Procfile{
+		Entries: []exec.ProcfileEntry{
+			{Name: "web", Command: command},
+			{Name: "worker", Command: command},
+		},
+		Output:  &out,
+		Scale:   map[string]int{"web": 2},
+		Env:     []string{"GREETING=hello", "DONE=" + dir},
+		Restart: exec.RestartNever,
+	}
+	if err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	sort.Strings(lines)
+	want := []string{
+		"web.1    | web.1 5000 hello",
+		"web.2    | web.2 5001 hello",
+		"worker.1 | worker.1 5100 hello",
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("want %q, got %q", want, lines)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestParseScale(t *testing.T) {
+	scale, err := exec.ParseScale("web=2, worker=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"web": 2, "worker": 0}; !reflect.DeepEqual(scale, want) {
+		t.Errorf("want %v, got %v", want, scale)
+	}
+	if _, err := exec.ParseScale("web"); err == nil {
+		t.Error("want error for missing count")
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	env, err := exec.ParseEnv(strings.NewReader(`
+# comment
+export A=1
+B = two words # comment
+C='$literal # not a comment'
+D="line\nbreak"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"A=1", "B=two words", "C=$literal # not a comment", "D=line\nbreak"}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("want %q, got %q", want, env)
+	}
+}
+
+func TestProcfileDependsOn(t *testing.T) {
+	var out bytes.Buffer
+	probes := 0
+	p := &exec.Procfile{
+		Entries: []exec.ProcfileEntry{
+			{Name: "web", Command: helperShell(t, "echo", "web"), DependsOn: []string{"db"}},
+			{Name: "db", Command: helperShell(t, "sleep", "10s"), Ready: func() error {
+				if probes++; probes < 3 {
+					return errors.New("not ready")
+				}
+				return nil
+			}},
+		},
+		Output:  &out,
+		Restart: exec.RestartNever,
+	}
+	if err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if probes != 3 {
+		t.Errorf("want web started after 3 probes of db, got %d", probes)
+	}
+	if got, want := out.String(), "web.1 | web\n"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	p = &exec.Procfile{Entries: []exec.ProcfileEntry{
+		{Name: "a", Command: "true", DependsOn: []string{"b"}},
+		{Name: "b", Command: "true", DependsOn: []string{"a"}},
+	}}
+	if err := p.Start(); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("want cycle error, got %v", err)
+	}
+}
+
+func TestProcfileStopOrder(t *testing.T) {
+	pr, pw := io.Pipe()
+	p := &exec.Procfile{
+		Entries: []exec.ProcfileEntry{
+			{Name: "db", Command: helperShell(t, "sleep", "10s")},
+			{Name: "web", Command: helperShell(t, "ignoresig"), DependsOn: []string{"db"}, StopGrace: 100 * time.Millisecond},
+		},
+		Output:    pw,
+		StopOrder: exec.StopInReverse,
+	}
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	// wait for web to ignore the signal before stopping it.
+	bufio.NewReader(pr).ReadString('\n')
+	if err := p.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	exits := p.Exits()
+	if len(exits) != 2 {
+		t.Fatalf("want 2 exits, got %v", exits)
+	}
+	if e := exits[0]; e.Name != "db.1" || !e.Stopped || !e.Clean() {
+		t.Errorf("want db.1 stopped cleanly, got %+v", e)
+	}
+	if e := exits[1]; e.Name != "web.1" || !e.Stopped || e.Clean() {
+		t.Errorf("want web.1 killed, got %+v", e)
+	}
+}
+
+// tabRenderer collects the output of each process separately.
+type tabRenderer struct {
+	mu     sync.Mutex
+	tabs   map[string]*bytes.Buffer
+	states []string
+}
+
+func (r *tabRenderer) Writers(names []string) []io.Writer {
+	r.tabs = make(map[string]*bytes.Buffer)
+	var ws []io.Writer
+	for _, name := range names {
+		r.tabs[name] = new(bytes.Buffer)
+		ws = append(ws, r.tabs[name])
+	}
+	return ws
+}
+
+func (r *tabRenderer) StateChanged(name string, state exec.State, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states = append(r.states, name+" "+state.String())
+}
+
+func TestProcfileRenderer(t *testing.T) {
+	r := new(tabRenderer)
+	p := &exec.Procfile{
+		Entries: []exec.ProcfileEntry{
+			{Name: "web", Command: helperShell(t, "echo", "hello")},
+		},
+		Renderer: r,
+		Restart:  exec.RestartNever,
+	}
+	if err := p.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := r.tabs["web.1"].String(), "hello\n"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if got, want := strings.Join(r.states, ", "), "web.1 starting, web.1 running, web.1 exited"; got != want {
+		t.Errorf("want states %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,37 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"syscall"
+)
+
+// DiskQuota limits the scratch space available to the child to bytes.
+//
+// A tmpfs of the given size is mounted on a fresh temporary directory which
+// is passed to the child as TMPDIR. The filesystem is unmounted and removed
+// once the command has exited. Mounting requires CAP_SYS_ADMIN.
+func DiskQuota(bytes int64) func(*Cmd) error {
+	return Describe("DiskQuota", []Param{{"bytes", bytes}}, func(c *Cmd) error {
+		if bytes <= 0 {
+			return errors.New("exec: DiskQuota must be positive")
+		}
+		dir, err := ioutil.TempDir("", "pkg-exec-")
+		if err != nil {
+			return err
+		}
+		if err := syscall.Mount("tmpfs", dir, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, fmt.Sprintf("size=%d,mode=1777", bytes)); err != nil {
+			os.Remove(dir)
+			return fmt.Errorf("exec: DiskQuota: mount tmpfs: %v", err)
+		}
+		c.finished = append(c.finished, func(*Cmd) error {
+			if err := syscall.Unmount(dir, syscall.MNT_DETACH); err != nil {
+				return fmt.Errorf("exec: DiskQuota: unmount tmpfs: %v", err)
+			}
+			return os.Remove(dir)
+		})
+		return Setenv("TMPDIR", dir)(c)
+	})
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package exec
+
+// DiskQuota limits the scratch space available to the child to bytes.
+// It is only supported on Linux.
+func DiskQuota(bytes int64) func(*Cmd) error {
+	return Describe("DiskQuota", []Param{{"bytes", bytes}}, func(*Cmd) error {
+		return notSupported("DiskQuota")
+	})
+}
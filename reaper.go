@@ -0,0 +1,54 @@
+package exec
+
+import (
+	"os/exec"
+	"sync"
+)
+
+// children records the processes started by commands, which Reaper
+// leaves for Wait to reap.
+var children struct {
+	starting sync.RWMutex // held for writing while reaping
+	sync.Mutex
+	pids map[int]bool
+}
+
+// startChild starts the command's process by calling start, and records
+// it in children before Reaper may reap it.
+func startChild(c *Cmd, start func() error) error {
+	children.starting.RLock()
+	defer children.starting.RUnlock()
+	if err := start(); err != nil {
+		return err
+	}
+	children.Lock()
+	if children.pids == nil {
+		children.pids = make(map[int]bool)
+	}
+	children.pids[c.Process.Pid] = true
+	children.Unlock()
+	return nil
+}
+
+// waitChild waits for the command's process, and removes it from
+// children.
+func waitChild(c *Cmd) error {
+	err := c.Cmd.Wait()
+	if c.Process != nil {
+		children.Lock()
+		delete(children.pids, c.Process.Pid)
+		children.Unlock()
+	}
+	return err
+}
+
+// runChild runs cmd, a helper such as tc started by this package for its
+// own use, recording it in children so that Reaper leaves it to be
+// waited for.
+func runChild(cmd *exec.Cmd) error {
+	c := &Cmd{Cmd: cmd}
+	if err := startChild(c, cmd.Start); err != nil {
+		return err
+	}
+	return waitChild(c)
+}
@@ -0,0 +1,101 @@
+package exec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+const prSetChildSubreaper = 36
+
+var reaper struct {
+	once sync.Once
+	err  error
+}
+
+// Reaper makes the current process a child subreaper, to which its
+// orphaned descendants are reparented, and reaps them as they exit, so
+// they do not accumulate as zombies. A program running as process 1 in
+// a container, to which all orphans in the container are reparented,
+// should call Reaper at start up, as should one which starts daemons.
+//
+// Processes started by commands, and by this package for its own use,
+// such as tc for NetRateLimit, are left for Wait to reap. Reaper must
+// not be used by a program which starts processes other than with this
+// package, such as with os/exec, as it may reap them before they are
+// waited for. Calling Reaper more than once has no further effect.
+func Reaper() error {
+	reaper.once.Do(func() {
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetChildSubreaper, 1, 0); errno != 0 {
+			reaper.err = fmt.Errorf("exec: Reaper: %v", errno)
+			return
+		}
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGCHLD)
+		go func() {
+			for range sigs {
+				reapOrphans()
+			}
+		}()
+		reapOrphans()
+	})
+	return reaper.err
+}
+
+// reapOrphans reaps those children of the current process which have
+// exited and were not started by commands.
+func reapOrphans() {
+	children.starting.Lock()
+	defer children.starting.Unlock()
+	for _, pid := range childPIDs() {
+		children.Lock()
+		ours := children.pids[pid]
+		children.Unlock()
+		if ours {
+			continue
+		}
+		var ws syscall.WaitStatus
+		syscall.Wait4(pid, &ws, syscall.WNOHANG, nil)
+	}
+}
+
+// childPIDs returns the process IDs of the current process's children,
+// from the children files of its threads if the kernel provides them,
+// and otherwise by scanning /proc.
+func childPIDs() []int {
+	var pids []int
+	if files, _ := filepath.Glob("/proc/self/task/*/children"); len(files) > 0 {
+		for _, file := range files {
+			b, _ := ioutil.ReadFile(file)
+			for _, f := range strings.Fields(string(b)) {
+				if pid, err := strconv.Atoi(f); err == nil {
+					pids = append(pids, pid)
+				}
+			}
+		}
+		return pids
+	}
+	self := os.Getpid()
+	dirs, _ := filepath.Glob("/proc/[0-9]*")
+	for _, dir := range dirs {
+		b, err := ioutil.ReadFile(filepath.Join(dir, "stat"))
+		if err != nil {
+			continue
+		}
+		// the parent's ID follows the state, after the command name,
+		// which may contain spaces.
+		f := strings.Fields(string(b[strings.LastIndexByte(string(b), ')')+1:]))
+		if len(f) > 1 && f[1] == strconv.Itoa(self) {
+			if pid, err := strconv.Atoi(filepath.Base(dir)); err == nil {
+				pids = append(pids, pid)
+			}
+		}
+	}
+	return pids
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package exec
+
+// Reaper reaps orphaned descendants of the current process. It is only
+// supported on Linux.
+func Reaper() error {
+	return notSupported("Reaper")
+}
@@ -0,0 +1,135 @@
+package exec
+
+import (
+	"regexp"
+	"strings"
+)
+
+// redacted replaces the secrets masked by Redact and RedactedArgs.
+const redacted = "REDACTED"
+
+// RedactEnv masks the values of the environment variables keys, as
+// passed to the child, wherever this package renders the command: in
+// the arguments and output recorded by Transcript, Manifest and
+// FlakeDetector, the records of Slog, the spans of WithTracing, the
+// output quoted by JSONError, and the options returned by
+// AppliedOptions.
+func RedactEnv(keys ...string) func(*Cmd) error {
+	return Describe("RedactEnv", []Param{{"keys", keys}}, func(c *Cmd) error {
+		c.redactEnv = append(c.redactEnv, keys...)
+		return nil
+	})
+}
+
+// RedactArgs masks the text matching the regular expressions patterns
+// wherever this package renders the command, as RedactEnv does. If a
+// pattern has a capturing group only the text it matches is masked, so
+// `--user=\w+:(\w+)` masks the password but not the user.
+func RedactArgs(patterns ...string) func(*Cmd) error {
+	return Describe("RedactArgs", []Param{{"patterns", patterns}}, func(c *Cmd) error {
+		for _, p := range patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return err
+			}
+			c.redactPatterns = append(c.redactPatterns, re)
+		}
+		return nil
+	})
+}
+
+// Redact returns s with the secrets set by RedactEnv and RedactArgs
+// replaced by "REDACTED", for hooks such as BeforeFunc which log the
+// command or its output.
+func (c *Cmd) Redact(s string) string {
+	for _, key := range c.redactEnv {
+		if v := c.getenv(key); v != "" {
+			s = strings.Replace(s, v, redacted, -1)
+		}
+	}
+	for _, re := range c.redactPatterns {
+		s = re.ReplaceAllStringFunc(s, func(m string) string {
+			sub := re.FindStringSubmatchIndex(m)
+			if len(sub) < 4 || sub[2] < 0 {
+				return redacted
+			}
+			return m[:sub[2]] + redacted + m[sub[3]:]
+		})
+	}
+	return s
+}
+
+// getenv returns the value of key in the child's environment.
+func (c *Cmd) getenv(key string) string {
+	v, _ := c.lookupEnv(key)
+	return v
+}
+
+// lookupEnv returns the value of key in the child's environment, and
+// whether it is set.
+func (c *Cmd) lookupEnv(key string) (string, bool) {
+	prefix := key + "="
+	for i := len(c.Env) - 1; i >= 0; i-- {
+		if strings.HasPrefix(c.Env[i], prefix) {
+			return c.Env[i][len(prefix):], true
+		}
+	}
+	return "", false
+}
+
+var (
+	secretFlag = regexp.MustCompile(`(?i)^--?[\w.-]*(pass|secret|token|key|auth|credential)[\w.-]*$`)
+	urlSecret  = regexp.MustCompile(`(\w+://[^:/@\s]*:)[^@/\s]+@`)
+)
+
+// RedactedArgs returns a copy of the command's arguments, including the
+// program, with likely secrets replaced by "REDACTED": the values of
+// flags whose names mention a password, secret, token, key, auth or
+// credential, as "--token=value" or "--token value", passwords in URLs,
+// and the secrets set by RedactEnv and RedactArgs. It is deliberately
+// broad, so may redact values which are not secret.
+func (c *Cmd) RedactedArgs() []string {
+	args := c.Args
+	out := make([]string, len(args))
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if j := strings.IndexByte(a, '='); j > 0 && secretFlag.MatchString(a[:j]) {
+			out[i] = a[:j] + "=" + redacted
+			continue
+		}
+		out[i] = c.Redact(urlSecret.ReplaceAllString(a, "${1}"+redacted+"@"))
+		if secretFlag.MatchString(a) && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			i++
+			out[i] = redacted
+		}
+	}
+	return out
+}
+
+// redactAll returns a copy of ss with the secrets masked by Redact.
+func (c *Cmd) redactAll(ss []string) []string {
+	out := make([]string, len(ss))
+	for i, s := range ss {
+		out[i] = c.Redact(s)
+	}
+	return out
+}
+
+// redactParams returns params with the secrets masked by Redact in
+// those which are strings.
+func (c *Cmd) redactParams(params []Param) []Param {
+	if len(c.redactEnv) == 0 && len(c.redactPatterns) == 0 {
+		return params
+	}
+	out := make([]Param, len(params))
+	for i, p := range params {
+		switch v := p.Value.(type) {
+		case string:
+			p.Value = c.Redact(v)
+		case []string:
+			p.Value = c.redactAll(v)
+		}
+		out[i] = p
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+package exec
+
+import (
+	"fmt"
+	"io"
+)
+
+// Renderer displays the output of the processes run by a Procfile.
+// Implementations may, for example, give each process its own pane in a
+// terminal UI.
+type Renderer interface {
+	// Writers is called with the names of the processes before any is
+	// started, and returns the writer for each to which its stdout and
+	// stderr are written. Each Write is a whole line, except possibly
+	// the last. Writers for different processes may be called
+	// concurrently.
+	Writers(names []string) []io.Writer
+
+	// StateChanged is called as each process changes state, with the
+	// error from its last run, see Supervisor.OnStateChange. It may be
+	// called concurrently.
+	StateChanged(name string, state State, err error)
+}
+
+// PlainRenderer returns a Renderer which writes the output of every
+// process to w, unchanged.
+func PlainRenderer(w io.Writer) Renderer {
+	return &prefixRenderer{w: &lockedWriter{w: w}, plain: true}
+}
+
+// PrefixRenderer returns a Renderer which writes the output of every
+// process to w, each line prefixed with the name of the process, and
+// optionally colored with ANSI escape codes. It is the default for a
+// Procfile.
+func PrefixRenderer(w io.Writer, color bool) Renderer {
+	return &prefixRenderer{w: &lockedWriter{w: w}, color: color}
+}
+
+type prefixRenderer struct {
+	w     io.Writer
+	plain bool
+	color bool
+}
+
+// procColors are the ANSI colors used for process prefixes, in turn.
+var procColors = [...]int{36, 33, 32, 35, 34, 31}
+
+func (r *prefixRenderer) Writers(names []string) []io.Writer {
+	width := 0
+	for _, name := range names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	ws := make([]io.Writer, len(names))
+	for i, name := range names {
+		if r.plain {
+			ws[i] = r.w
+			continue
+		}
+		prefix := fmt.Sprintf("%-*s | ", width, name)
+		if r.color {
+			prefix = fmt.Sprintf("\x1b[%dm%s\x1b[0m", procColors[i%len(procColors)], prefix)
+		}
+		ws[i] = &prefixWriter{w: r.w, prefix: []byte(prefix)}
+	}
+	return ws
+}
+
+func (r *prefixRenderer) StateChanged(string, State, error) {}
+
+// prefixWriter writes prefix before each Write to w, in a single Write.
+type prefixWriter struct {
+	w      io.Writer
+	prefix []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	if _, err := p.w.Write(append(p.prefix[:len(p.prefix):len(p.prefix)], b...)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
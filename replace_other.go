@@ -0,0 +1,12 @@
+//go:build windows || plan9 || js || wasip1
+// +build windows plan9 js wasip1
+
+package exec
+
+// Replace replaces the current process with the named program. It is
+// not supported on Windows, Plan 9, js or wasip1, which have no
+// equivalent of execve(2); use Command and exit with its exit code
+// instead.
+func Replace(name string, args, env []string, opts ...func(*Cmd) error) error {
+	return notSupported("Replace")
+}
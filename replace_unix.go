@@ -0,0 +1,78 @@
+//go:build !windows && !plan9 && !js && !wasip1
+// +build !windows,!plan9,!js,!wasip1
+
+package exec
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// Replace replaces the current process with the named program, as
+// execve(2), so a wrapper may hand off to the program entirely rather
+// than waiting for it as a parent. The program is looked up in PATH as
+// by Command, and runs with args and env; if env is nil it inherits the
+// environment of the current process. opts are applied as they would be
+// by Cmd.Start, so Dir, Setenv and the like may be used, and Dir is
+// entered with chdir before the program is run.
+//
+// The program inherits the stdio of the current process. Options which
+// redirect stdio, pass extra files, set process attributes such as
+// Chroot, NewSession or Cgroup, or act once the child has started or
+// exited cannot be honoured, and are reported as errors. Replace only
+// returns if the program could not be run, after restoring the working
+// directory.
+func Replace(name string, args, env []string, opts ...func(*Cmd) error) error {
+	c := Command(name, args...)
+	c.Env = env
+	if err := configureReplace(c, opts...); err != nil {
+		// release anything acquired by options which were applied.
+		c.runFinished()
+		return err
+	}
+	if c.Dir != "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		if err := os.Chdir(c.Dir); err != nil {
+			return err
+		}
+		defer os.Chdir(wd)
+	}
+	return &os.PathError{Op: "exec", Path: c.Path, Err: syscall.Exec(c.Path, c.Args, c.Env)}
+}
+
+// configureReplace applies opts to c, returning an error if c is then
+// configured in a way Replace cannot honour.
+func configureReplace(c *Cmd, opts ...func(*Cmd) error) error {
+	if err := c.configure(opts...); err != nil {
+		return err
+	}
+	if err := c.resolve(); err != nil {
+		return err
+	}
+	std := []struct {
+		got, want interface{}
+	}{
+		{c.Stdin, os.Stdin},
+		{c.Stdout, os.Stdout},
+		{c.Stderr, os.Stderr},
+	}
+	for _, s := range std {
+		if s.got != nil && s.got != s.want {
+			return errors.New("exec: Replace cannot redirect stdio")
+		}
+	}
+	if len(c.ExtraFiles) > 0 {
+		return errors.New("exec: Replace cannot pass ExtraFiles")
+	}
+	if len(c.started) > 0 || len(c.finished) > 0 {
+		return errors.New("exec: Replace cannot run options which act after the program starts")
+	}
+	if c.SysProcAttr != nil || c.spawn != nil {
+		return errors.New("exec: Replace cannot run options which set process attributes")
+	}
+	return nil
+}
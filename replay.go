@@ -0,0 +1,68 @@
+package exec
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Replayer writes the output recorded by a Transcript with its original
+// timing, so a failed automated run may be watched as it happened. The
+// zero value replays at the original speed.
+type Replayer struct {
+	// Speed scales the replay; 2 replays twice as fast. The default is 1.
+	Speed float64
+
+	// MaxGap, if positive, caps each pause between lines, after scaling
+	// by Speed, so long idle periods are skipped over.
+	MaxGap time.Duration
+
+	// Clock, if non nil, is used to time the replay.
+	Clock Clock
+}
+
+// Replay writes each entry to w as a terminal would have shown it: the
+// command line, preceded by "$ ", then each line of its output, written
+// as long after the previous line as it was recorded. Stdout and stderr
+// are both written to w.
+func (r *Replayer) Replay(w io.Writer, entries []TranscriptEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	clk := r.Clock
+	if clk == nil {
+		clk = systemClock{}
+	}
+	speed := r.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	prev := entries[0].Start
+	pause := func(t time.Time) {
+		d := time.Duration(float64(t.Sub(prev)) / speed)
+		if t.After(prev) {
+			prev = t
+		}
+		if r.MaxGap > 0 && d > r.MaxGap {
+			d = r.MaxGap
+		}
+		if d > 0 {
+			expired, _ := after(clk, d)
+			<-expired
+		}
+	}
+	for _, e := range entries {
+		pause(e.Start)
+		if _, err := fmt.Fprintf(w, "$ %s\n", strings.Join(e.Args, " ")); err != nil {
+			return err
+		}
+		for _, l := range e.Output {
+			pause(l.Time)
+			if _, err := io.WriteString(w, l.Text+"\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
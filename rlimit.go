@@ -0,0 +1,14 @@
+package exec
+
+// Resource is a resource of the child which may be constrained by Limit.
+type Resource int
+
+const (
+	LimitNoFile Resource = iota // open files
+	LimitCore                   // core file size, in bytes
+	LimitCPU                    // CPU time, in seconds
+	LimitAS                     // address space, in bytes
+)
+
+// Unlimited may be passed to Limit as either limit to remove it.
+const Unlimited = ^uint64(0)
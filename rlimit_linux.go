@@ -0,0 +1,71 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// limitsEnv is the environment variable in which Limit passes the
+// limits to the sandbox shim, as a comma separated list of
+// resource=soft:hard.
+const limitsEnv = "EXEC_RLIMITS"
+
+var rlimitResources = [...]int{
+	LimitNoFile: syscall.RLIMIT_NOFILE,
+	LimitCore:   syscall.RLIMIT_CORE,
+	LimitCPU:    syscall.RLIMIT_CPU,
+	LimitAS:     syscall.RLIMIT_AS,
+}
+
+// Limit constrains the child's use of resource to the soft limit, which
+// the child may raise as far as the hard limit. The limits are set by
+// the sandbox shim, the current executable run by Self, before it
+// executes the program, so the main function must begin by calling
+// Reexec, and the shim must be present within any root set by Chroot.
+// Raising a hard limit requires CAP_SYS_RESOURCE.
+func Limit(resource Resource, soft, hard uint64) func(*Cmd) error {
+	return Describe("Limit", []Param{{"resource", resource}, {"soft", soft}, {"hard", hard}}, func(c *Cmd) error {
+		if resource < 0 || int(resource) >= len(rlimitResources) {
+			return fmt.Errorf("exec: Limit: unknown resource %d", resource)
+		}
+		if soft > hard {
+			return fmt.Errorf("exec: Limit: soft limit %d exceeds hard limit %d", soft, hard)
+		}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			limit := fmt.Sprintf("%d=%d:%d", rlimitResources[resource], soft, hard)
+			if limits := c.getenv(limitsEnv); limits != "" {
+				limit = limits + "," + limit
+			}
+			return applyOptions(c, Setenv(limitsEnv, limit))
+		})
+		return c.sandbox()
+	})
+}
+
+// setLimits sets the resource limits passed by Limit in limits.
+func setLimits(limits string) error {
+	for _, l := range strings.Split(limits, ",") {
+		i, j := strings.IndexByte(l, '='), strings.IndexByte(l, ':')
+		if i < 0 || j < i {
+			return errors.New("malformed limit " + l)
+		}
+		resource, err := strconv.Atoi(l[:i])
+		if err != nil {
+			return err
+		}
+		var lim syscall.Rlimit
+		if lim.Cur, err = strconv.ParseUint(l[i+1:j], 10, 64); err != nil {
+			return err
+		}
+		if lim.Max, err = strconv.ParseUint(l[j+1:], 10, 64); err != nil {
+			return err
+		}
+		if err := syscall.Setrlimit(resource, &lim); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package exec
+
+// Limit constrains the child's use of resource to the soft limit, which
+// the child may raise as far as the hard limit. It is only supported on
+// Linux.
+func Limit(resource Resource, soft, hard uint64) func(*Cmd) error {
+	return Describe("Limit", []Param{{"resource", resource}, {"soft", soft}, {"hard", hard}}, func(*Cmd) error {
+		return notSupported("Limit")
+	})
+}
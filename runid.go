@@ -0,0 +1,36 @@
+package exec
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+)
+
+// Environment variables set by Correlate.
+const (
+	RunIDEnv       = "EXEC_RUN_ID"
+	ParentRunIDEnv = "EXEC_PARENT_RUN_ID"
+)
+
+// Correlate assigns the command a random run ID, available from RunID
+// and recorded by Transcript and RecordManifest, and passes it to the
+// child as EXEC_RUN_ID. If the parent was itself run with Correlate, its
+// run ID is passed to the child as EXEC_PARENT_RUN_ID, so the commands of
+// a workflow spanning several processes can be related.
+func Correlate() func(*Cmd) error {
+	return Describe("Correlate", nil, func(c *Cmd) error {
+		var b [16]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return err
+		}
+		c.runID = hex.EncodeToString(b[:])
+		opts := []func(*Cmd) error{Setenv(RunIDEnv, c.runID)}
+		if parent := os.Getenv(RunIDEnv); parent != "" {
+			opts = append(opts, Setenv(ParentRunIDEnv, parent))
+		}
+		return applyOptions(c, opts...)
+	})
+}
+
+// RunID returns the run ID assigned by Correlate, or "" if there is none.
+func (c *Cmd) RunID() string { return c.runID }
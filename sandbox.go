@@ -0,0 +1,35 @@
+//go:build linux || freebsd
+// +build linux freebsd
+
+package exec
+
+import "os"
+
+// sandboxRole is the role in which the current executable is run, by
+// Self, as a shim which restricts itself as set by options such as
+// Seccomp on Linux and Jail on FreeBSD before executing the program in
+// its place.
+const sandboxRole = "exec-sandbox"
+
+func init() {
+	RegisterReexec(sandboxRole, runSandbox)
+}
+
+// sandbox arranges for the child to be run by the sandbox shim, which
+// applies the restrictions passed to it in the environment.
+func (c *Cmd) sandbox() error {
+	if c.sandboxed {
+		return nil
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	c.sandboxed = true
+	c.starting = append(c.starting, func(c *Cmd) error {
+		c.Args = append([]string{sandboxRole, c.Path}, c.Args...)
+		c.Path = self
+		return nil
+	})
+	return nil
+}
@@ -0,0 +1,78 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// runSandbox attaches the process to the jail and enters capability
+// mode as set in the environment, and executes the program, os.Args[1],
+// in place of the process.
+func runSandbox() {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, "exec: sandbox: no program")
+		os.Exit(127)
+	}
+	jail, capsicum := os.Getenv(jailEnv), os.Getenv(capsicumEnv) != ""
+	os.Unsetenv(jailEnv)
+	os.Unsetenv(capsicumEnv)
+
+	if jail != "" {
+		jid, err := strconv.Atoi(jail)
+		if err == nil {
+			_, _, errno := syscall.Syscall(syscall.SYS_JAIL_ATTACH, uintptr(jid), 0, 0)
+			if errno != 0 {
+				err = errno
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "exec: Jail: %v\n", err)
+			os.Exit(127)
+		}
+	}
+	if !capsicum {
+		err := syscall.Exec(os.Args[1], os.Args[2:], os.Environ())
+		fmt.Fprintf(os.Stderr, "exec: sandbox: %s: %v\n", os.Args[1], err)
+		os.Exit(127)
+	}
+	err := execCapsicum(os.Args[1], os.Args[2:])
+	fmt.Fprintf(os.Stderr, "exec: Capsicum: %s: %v\n", os.Args[1], err)
+	os.Exit(127)
+}
+
+// execCapsicum enters capability mode and executes the program at path
+// by its file descriptor, as it may no longer be opened by path. The
+// directories of shared libraries are passed to the dynamic linker in
+// LD_LIBRARY_PATH_FDS.
+func execCapsicum(path string, args []string) error {
+	// the descriptors are inherited by the program, so are not opened
+	// close on exec.
+	fd, err := syscall.Open(path, syscall.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	var libs []string
+	for _, dir := range []string{"/lib", "/usr/lib", "/usr/local/lib"} {
+		if d, err := syscall.Open(dir, syscall.O_RDONLY|syscall.O_DIRECTORY, 0); err == nil {
+			libs = append(libs, strconv.Itoa(d))
+		}
+	}
+	env := append(os.Environ(), "LD_LIBRARY_PATH_FDS="+strings.Join(libs, ":"))
+	argv, err := syscall.SlicePtrFromStrings(args)
+	if err != nil {
+		return err
+	}
+	envv, err := syscall.SlicePtrFromStrings(env)
+	if err != nil {
+		return err
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_CAP_ENTER, 0, 0, 0); errno != 0 {
+		return errno
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_FEXECVE, uintptr(fd), uintptr(unsafe.Pointer(&argv[0])), uintptr(unsafe.Pointer(&envv[0])))
+	return errno
+}
@@ -0,0 +1,77 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// runSandbox restricts the current thread as set in the environment,
+// and executes the program, os.Args[1], in place of the process. The
+// restrictions apply to the thread which executes the program, so the
+// Go runtime's other threads are unaffected.
+func runSandbox() {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, "exec: sandbox: no program")
+		os.Exit(127)
+	}
+	overlay := os.Getenv(overlayEnv)
+	rules, filter := os.Getenv(landlockEnv), os.Getenv(seccompEnv)
+	keep, dropCaps := os.LookupEnv(capsEnv)
+	limits := os.Getenv(limitsEnv)
+	os.Unsetenv(overlayEnv)
+	os.Unsetenv(landlockEnv)
+	os.Unsetenv(seccompEnv)
+	os.Unsetenv(capsEnv)
+	os.Unsetenv(limitsEnv)
+	env := os.Environ()
+
+	runtime.LockOSThread()
+	if overlay != "" {
+		if err := mountOverlay(overlay); err != nil {
+			fmt.Fprintf(os.Stderr, "exec: OverlayRoot: %v\n", err)
+			os.Exit(127)
+		}
+	}
+	if limits != "" {
+		if err := setLimits(limits); err != nil {
+			fmt.Fprintf(os.Stderr, "exec: Limit: %v\n", err)
+			os.Exit(127)
+		}
+	}
+	if dropCaps {
+		if err := dropCapabilities(keep); err != nil {
+			fmt.Fprintf(os.Stderr, "exec: DropCapabilities: %v\n", err)
+			os.Exit(127)
+		}
+	}
+	// no_new_privs, which Landlock and Seccomp require, also stops the
+	// program from gaining privileges by executing setuid programs, so it
+	// is only set if they are used.
+	if rules != "" || filter != "" {
+		if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0, 0, 0, 0); errno != 0 {
+			fmt.Fprintf(os.Stderr, "exec: sandbox: setting no_new_privs: %v\n", errno)
+			os.Exit(127)
+		}
+	}
+	if rules != "" {
+		if err := restrictLandlock(rules); err != nil {
+			fmt.Fprintf(os.Stderr, "exec: Landlock: %v\n", err)
+			os.Exit(127)
+		}
+	}
+	// the filter is installed last, as it may deny the system calls
+	// used to apply the other restrictions.
+	if filter != "" {
+		if err := installSeccomp(filter); err != nil {
+			fmt.Fprintf(os.Stderr, "exec: Seccomp: %v\n", err)
+			os.Exit(127)
+		}
+	}
+	err := syscall.Exec(os.Args[1], os.Args[2:], env)
+	fmt.Fprintf(os.Stderr, "exec: sandbox: %s: %v\n", os.Args[1], err)
+	os.Exit(127)
+}
+
+const prSetNoNewPrivs = 38
@@ -0,0 +1,190 @@
+package exec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule determines when a Scheduler runs its command.
+type Schedule interface {
+	// Next returns the first time after t at which the command should
+	// run, or the zero time if it should not run again.
+	Next(t time.Time) time.Time
+}
+
+// Every returns a Schedule which runs the command every d, starting d
+// after the Scheduler is started.
+func Every(d time.Duration) Schedule { return every(d) }
+
+type every time.Duration
+
+func (d every) Next(t time.Time) time.Time {
+	if d <= 0 {
+		return time.Time{}
+	}
+	return t.Add(time.Duration(d))
+}
+
+func (d every) String() string { return "every " + time.Duration(d).String() }
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	cronMonths = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	cronDays = map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+)
+
+// ParseCron parses a standard five field cron expression, "minute hour
+// day-of-month month day-of-week", as used by crontab(5). Fields may be
+// "*", values, ranges such as "1-5", lists such as "1,15", and steps
+// such as "*/10" or "0-30/5". Months and days of the week may be given
+// by their first three letters, and Sunday is 0 or 7. As in cron, if
+// both the day of the month and the day of the week are restricted, a
+// day matching either runs the command. The macros @yearly, @monthly,
+// @weekly, @daily and @hourly are also accepted. Times are in the
+// location of the time passed to Next.
+func ParseCron(expr string) (Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if m, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = m
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("exec: cron expression %q: want 5 fields, got %d", expr, len(fields))
+	}
+	var c cron
+	var err error
+	parse := func(i, min, max int, names map[string]int) uint64 {
+		if err != nil {
+			return 0
+		}
+		var bits uint64
+		if bits, err = parseCronField(fields[i], min, max, names); err != nil {
+			err = fmt.Errorf("exec: cron expression %q: %v", expr, err)
+		}
+		return bits
+	}
+	c.minute = parse(0, 0, 59, nil)
+	c.hour = parse(1, 0, 23, nil)
+	c.dom = parse(2, 1, 31, nil)
+	c.month = parse(3, 1, 12, cronMonths)
+	c.dow = parse(4, 0, 7, cronDays)
+	if err != nil {
+		return nil, err
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // Sunday
+	}
+	c.anyDay = strings.HasPrefix(fields[2], "*") || strings.HasPrefix(fields[4], "*")
+	c.expr = expr
+	return &c, nil
+}
+
+// cron is a Schedule parsed by ParseCron. Each field is a bit set of the
+// values which match.
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	anyDay                        bool // the day matches if both dom and dow do
+	expr                          string
+}
+
+func (c *cron) String() string { return c.expr }
+
+func (c *cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// five years covers every valid expression, including 29 February
+	// on a given day of the week.
+	for limit := t.Year() + 5; t.Year() <= limit; {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c *cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.anyDay {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// parseCronField returns the bit set of the values between min and max
+// matched by a field of a cron expression.
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	value := func(s string) (int, error) {
+		if n, ok := names[strings.ToLower(s)]; ok {
+			return n, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("bad value %q", s)
+		}
+		return n, nil
+	}
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		r, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("bad step in %q", part)
+			}
+			r = part[:i]
+		}
+		lo, hi := min, max
+		if r != "*" {
+			var err error
+			if i := strings.IndexByte(r, '-'); i >= 0 {
+				if lo, err = value(r[:i]); err != nil {
+					return 0, err
+				}
+				if hi, err = value(r[i+1:]); err != nil {
+					return 0, err
+				}
+			} else {
+				if lo, err = value(r); err != nil {
+					return 0, err
+				}
+				hi = lo
+				if step > 1 {
+					hi = max
+				}
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
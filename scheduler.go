@@ -0,0 +1,268 @@
+package exec
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrRunSkipped is reported to Scheduler.OnRun for a run which was due
+// while the previous run was still running, under OverlapSkip, or which
+// was replaced in the queue by a later run, under OverlapQueue.
+var ErrRunSkipped = errors.New("exec: run skipped, the previous run is still running")
+
+// OverlapPolicy controls what a Scheduler does when a run is due while
+// the previous run is still running.
+type OverlapPolicy int
+
+const (
+	OverlapSkip  OverlapPolicy = iota // skip the run
+	OverlapQueue                      // start the run once the previous one exits; at most one run is queued
+	OverlapKill                       // stop the previous run, then start the run
+)
+
+// Scheduler runs a command periodically, according to a Schedule, as
+// cron does. Each run starts a fresh Cmd from Spec.
+// A Scheduler must not be copied after first use.
+type Scheduler struct {
+	Spec     Spec     // the command to run
+	Schedule Schedule // when to run it, see Every and ParseCron
+
+	// Overlap controls what happens when a run is due while the previous
+	// run is still running. The default is OverlapSkip.
+	Overlap OverlapPolicy
+
+	// Jitter, if positive, delays each run by a random duration of up to
+	// Jitter, so that many schedulers do not run their commands at once.
+	Jitter time.Duration
+
+	// StopSignal and StopGrace control how a run is stopped by Stop or
+	// OverlapKill, see Cmd.Stop. The defaults are os.Interrupt and 10s.
+	StopSignal os.Signal
+	StopGrace  time.Duration
+
+	// OnRun, if non nil, is called with the result of each run, or of
+	// starting it, and for each skipped run. It is called from another
+	// goroutine, and calls are not serialised.
+	OnRun func(ScheduledRun)
+
+	// Clock, if non nil, is used for the schedule and by each run of the
+	// command, see UseClock.
+	Clock Clock
+
+	mu       sync.Mutex
+	current  *scheduledRun // the running run, if any
+	pending  *time.Time    // the due time of the queued run, if any
+	stopping bool
+	runs     sync.WaitGroup
+	stopc    chan struct{}
+	done     chan struct{}
+}
+
+// ScheduledRun is the result of a run of a Scheduler's command.
+type ScheduledRun struct {
+	Due      time.Time     // when the run was due, before jitter
+	Start    time.Time     // when the command was started, zero if it was skipped
+	Duration time.Duration // how long the command ran
+	Err      error         // the error from starting or waiting for the command, or ErrRunSkipped
+}
+
+// scheduledRun is a running run of the command.
+type scheduledRun struct {
+	cmd      *Cmd // nil while starting
+	stopping bool
+	kill     Timer // kills the command once StopGrace has elapsed
+}
+
+// Start starts running the command on its schedule.
+func (s *Scheduler) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done != nil {
+		return errors.New("exec: Scheduler already started")
+	}
+	if s.Schedule == nil {
+		return errors.New("exec: Scheduler has no Schedule")
+	}
+	s.stopc = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.loop()
+	return nil
+}
+
+// Stop stops scheduling runs, stops any running run of the command, and
+// waits for it to exit.
+func (s *Scheduler) Stop() error {
+	s.mu.Lock()
+	if s.done == nil {
+		s.mu.Unlock()
+		return errors.New("exec: Scheduler not started")
+	}
+	select {
+	case <-s.stopc:
+	default:
+		close(s.stopc)
+	}
+	s.mu.Unlock()
+	<-s.done
+	return nil
+}
+
+// Wait waits for the scheduler to exit, either because Stop was called
+// or because the schedule has no more runs and the last run has exited.
+func (s *Scheduler) Wait() error {
+	s.mu.Lock()
+	done := s.done
+	s.mu.Unlock()
+	if done == nil {
+		return errors.New("exec: Scheduler not started")
+	}
+	<-done
+	return nil
+}
+
+func (s *Scheduler) loop() {
+	defer close(s.done)
+	clk := s.clock()
+	last := clk.Now()
+	for {
+		due := s.Schedule.Next(last)
+		if due.IsZero() {
+			s.runs.Wait()
+			return
+		}
+		wait := due.Sub(clk.Now())
+		if s.Jitter > 0 {
+			wait += time.Duration(rand.Int63n(int64(s.Jitter)))
+		}
+		expired, stop := after(clk, wait)
+		select {
+		case <-expired:
+		case <-s.stopc:
+			stop()
+			s.mu.Lock()
+			s.stopping, s.pending = true, nil
+			if s.current != nil {
+				s.stop(s.current)
+			}
+			s.mu.Unlock()
+			s.runs.Wait()
+			return
+		}
+		s.due(due)
+		// runs missed while the clock jumped forward, or the process
+		// was suspended, are not made up.
+		last = due
+		if now := clk.Now(); now.Sub(due) > s.Jitter+time.Second {
+			last = now
+		}
+	}
+}
+
+// due starts or queues the run due at the given time, according to the
+// overlap policy.
+func (s *Scheduler) due(at time.Time) {
+	s.mu.Lock()
+	if s.current == nil {
+		s.run(at)
+		s.mu.Unlock()
+		return
+	}
+	var skipped *time.Time
+	switch s.Overlap {
+	case OverlapQueue:
+		skipped, s.pending = s.pending, &at
+	case OverlapKill:
+		s.pending = &at
+		s.stop(s.current)
+	default:
+		skipped = &at
+	}
+	fn := s.OnRun
+	s.mu.Unlock()
+	if skipped != nil && fn != nil {
+		fn(ScheduledRun{Due: *skipped, Err: ErrRunSkipped})
+	}
+}
+
+// run starts a run due at the given time. s.mu must be held, and no
+// other run may be running.
+func (s *Scheduler) run(at time.Time) {
+	r := &scheduledRun{}
+	s.current = r
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		clk := s.clock()
+		cmd := s.Spec.Command()
+		var opts []func(*Cmd) error
+		if s.Clock != nil {
+			opts = append(opts, UseClock(s.Clock))
+		}
+		res := ScheduledRun{Due: at, Start: clk.Now()}
+		err := cmd.Start(opts...)
+		if err == nil {
+			s.mu.Lock()
+			r.cmd = cmd
+			if r.stopping {
+				s.stop(r)
+			}
+			s.mu.Unlock()
+			err = cmd.Wait()
+		}
+		res.Duration, res.Err = clk.Now().Sub(res.Start), err
+
+		s.mu.Lock()
+		if r.kill != nil {
+			r.kill.Stop()
+		}
+		s.current = nil
+		if s.pending != nil && !s.stopping {
+			s.run(*s.pending)
+		}
+		s.pending = nil
+		fn := s.OnRun
+		s.mu.Unlock()
+		if fn != nil {
+			fn(res)
+		}
+	}()
+}
+
+// stop asks the run to exit, killing it if it has not done so within
+// StopGrace. s.mu must be held.
+func (s *Scheduler) stop(r *scheduledRun) {
+	r.stopping = true
+	if r.cmd == nil || r.kill != nil {
+		return // stopped once started, or already stopping
+	}
+	cmd := r.cmd
+	if err := cmd.Process.Signal(s.stopSignal()); err != nil {
+		cmd.Process.Kill()
+		return
+	}
+	r.kill = s.clock().AfterFunc(s.stopGrace(), func() { cmd.Process.Kill() })
+}
+
+func (s *Scheduler) clock() Clock {
+	if s.Clock == nil {
+		return systemClock{}
+	}
+	return s.Clock
+}
+
+func (s *Scheduler) stopSignal() os.Signal {
+	if s.StopSignal == nil {
+		return os.Interrupt
+	}
+	return s.StopSignal
+}
+
+func (s *Scheduler) stopGrace() time.Duration {
+	if s.StopGrace <= 0 {
+		return 10 * time.Second
+	}
+	return s.StopGrace
+}
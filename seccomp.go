@@ -0,0 +1,78 @@
+package exec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// SeccompProfile is a system call filter for Seccomp, with the JSON
+// form of the profiles used by Docker and other container runtimes, so
+// such a profile may be decoded into it with encoding/json.
+type SeccompProfile struct {
+	// DefaultAction is taken for system calls no rule applies to. The
+	// actions are SCMP_ACT_ALLOW, SCMP_ACT_ERRNO, which fails the system
+	// call with the rule's ErrnoRet or EPERM, SCMP_ACT_LOG, SCMP_ACT_TRAP,
+	// SCMP_ACT_KILL (or SCMP_ACT_KILL_THREAD) and SCMP_ACT_KILL_PROCESS.
+	DefaultAction   string        `json:"defaultAction"`
+	DefaultErrnoRet *uint         `json:"defaultErrnoRet,omitempty"`
+	Syscalls        []SeccompRule `json:"syscalls,omitempty"`
+}
+
+// SeccompRule sets the action taken for the named system calls. The
+// first rule naming a system call applies to it. Names unknown on the
+// current architecture are ignored.
+//
+// Rules conditional on the arguments of the system call, on
+// capabilities or on the kernel version are not supported, and are
+// skipped, so a profile denying by default stays at least as strict.
+// Rules for other architectures are also skipped.
+type SeccompRule struct {
+	Names    []string          `json:"names"`
+	Action   string            `json:"action"`
+	ErrnoRet *uint             `json:"errnoRet,omitempty"`
+	Args     []json.RawMessage `json:"args,omitempty"`
+	Includes SeccompCondition  `json:"includes,omitempty"`
+	Excludes SeccompCondition  `json:"excludes,omitempty"`
+}
+
+// SeccompCondition restricts the hosts a SeccompRule applies to.
+// Architectures are named as by GOARCH.
+type SeccompCondition struct {
+	Arches    []string `json:"arches,omitempty"`
+	Caps      []string `json:"caps,omitempty"`
+	MinKernel string   `json:"minKernel,omitempty"`
+}
+
+// SeccompAllowlist returns a profile allowing only the named system
+// calls, failing others with EPERM. The program must be allowed every
+// system call it, its dynamic loader and its runtime make, and execve
+// to start.
+func SeccompAllowlist(names ...string) *SeccompProfile {
+	return &SeccompProfile{
+		DefaultAction: "SCMP_ACT_ERRNO",
+		Syscalls:      []SeccompRule{{Names: names, Action: "SCMP_ACT_ALLOW"}},
+	}
+}
+
+// SeccompDenylist returns a profile failing the named system calls with
+// EPERM, and allowing all others.
+func SeccompDenylist(names ...string) *SeccompProfile {
+	return &SeccompProfile{
+		DefaultAction: "SCMP_ACT_ALLOW",
+		Syscalls:      []SeccompRule{{Names: names, Action: "SCMP_ACT_ERRNO"}},
+	}
+}
+
+// ReadSeccompProfile reads a JSON profile from the named file.
+func ReadSeccompProfile(path string) (*SeccompProfile, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p SeccompProfile
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("exec: %s: %w", path, err)
+	}
+	return &p, nil
+}
@@ -0,0 +1,178 @@
+package exec
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// seccompEnv is the environment variable in which Seccomp passes the
+// compiled filter to the sandbox shim.
+const seccompEnv = "EXEC_SECCOMP_FILTER"
+
+// Seccomp runs the child with the system call filter profile. The
+// filter is installed, with no_new_privs set, by the sandbox shim, the
+// current executable run by Self, which then executes the program, so
+// the main function must begin by calling Reexec. The program inherits
+// the filter, which it and its children cannot remove.
+//
+//	cmd.Run(exec.Seccomp(exec.SeccompDenylist("ptrace", "mount", "umount2")))
+//
+// Seccomp is supported on amd64 and arm64.
+func Seccomp(profile *SeccompProfile) func(*Cmd) error {
+	return Describe("Seccomp", []Param{{"profile", profile}}, func(c *Cmd) error {
+		if seccompSyscalls == nil {
+			return fmt.Errorf("exec: Seccomp %w on %s/%s", ErrNotSupported, runtime.GOOS, runtime.GOARCH)
+		}
+		filter, err := profile.compile()
+		if err != nil {
+			return fmt.Errorf("exec: Seccomp: %w", err)
+		}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			return applyOptions(c, Setenv(seccompEnv, base64.StdEncoding.EncodeToString(filter)))
+		})
+		return c.sandbox()
+	})
+}
+
+// seccomp return values, from linux/seccomp.h.
+const (
+	seccompRetKillProcess = 0x80000000
+	seccompRetKillThread  = 0x00000000
+	seccompRetTrap        = 0x00030000
+	seccompRetErrno       = 0x00050000
+	seccompRetLog         = 0x7ffc0000
+	seccompRetAllow       = 0x7fff0000
+)
+
+// classic BPF opcodes, from linux/filter.h.
+const (
+	bpfLdAbsW = 0x20 // BPF_LD | BPF_W | BPF_ABS
+	bpfJeqK   = 0x15 // BPF_JMP | BPF_JEQ | BPF_K
+	bpfJgeK   = 0x35 // BPF_JMP | BPF_JGE | BPF_K
+	bpfRetK   = 0x06 // BPF_RET | BPF_K
+)
+
+// x32SyscallBit marks the system calls of the x32 ABI on x86-64.
+const x32SyscallBit = 0x40000000
+
+type sockFilter struct {
+	code   uint16
+	jt, jf uint8
+	k      uint32
+}
+
+func seccompAction(action string, errnoRet *uint) (uint32, error) {
+	switch action {
+	case "SCMP_ACT_ALLOW":
+		return seccompRetAllow, nil
+	case "SCMP_ACT_ERRNO":
+		errno := uint32(syscall.EPERM)
+		if errnoRet != nil {
+			errno = uint32(*errnoRet) & 0xffff
+		}
+		return seccompRetErrno | errno, nil
+	case "SCMP_ACT_LOG":
+		return seccompRetLog, nil
+	case "SCMP_ACT_TRAP":
+		return seccompRetTrap, nil
+	case "SCMP_ACT_KILL", "SCMP_ACT_KILL_THREAD":
+		return seccompRetKillThread, nil
+	case "SCMP_ACT_KILL_PROCESS":
+		return seccompRetKillProcess, nil
+	}
+	return 0, fmt.Errorf("unsupported action %q", action)
+}
+
+// applies reports whether the rule applies on this host.
+func (r *SeccompRule) applies() bool {
+	if len(r.Args) > 0 || len(r.Includes.Caps) > 0 || r.Includes.MinKernel != "" {
+		return false
+	}
+	if len(r.Includes.Arches) > 0 && !contains(r.Includes.Arches, runtime.GOARCH) {
+		return false
+	}
+	return !contains(r.Excludes.Arches, runtime.GOARCH)
+}
+
+// compile compiles the profile to a classic BPF program, encoded as the
+// array of struct sock_filter the kernel expects on a little endian
+// host.
+func (p *SeccompProfile) compile() ([]byte, error) {
+	def, err := seccompAction(p.DefaultAction, p.DefaultErrnoRet)
+	if err != nil {
+		return nil, err
+	}
+	prog := []sockFilter{
+		{code: bpfLdAbsW, k: 4}, // seccomp_data.arch
+		{code: bpfJeqK, jt: 1, k: seccompArch},
+		{code: bpfRetK, k: seccompRetKillProcess},
+		{code: bpfLdAbsW, k: 0}, // seccomp_data.nr
+	}
+	if seccompArch == 0xc000003e {
+		// deny the x32 ABI, whose system call numbers the rules would
+		// otherwise not match.
+		prog = append(prog,
+			sockFilter{code: bpfJgeK, jf: 1, k: x32SyscallBit},
+			sockFilter{code: bpfRetK, k: seccompRetErrno | uint32(syscall.ENOSYS)},
+		)
+	}
+	seen := make(map[uint32]bool)
+	for _, r := range p.Syscalls {
+		if !r.applies() {
+			continue
+		}
+		action, err := seccompAction(r.Action, r.ErrnoRet)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range r.Names {
+			nr, ok := seccompSyscalls[name]
+			if !ok || seen[nr] {
+				continue
+			}
+			seen[nr] = true
+			prog = append(prog,
+				sockFilter{code: bpfJeqK, jf: 1, k: nr},
+				sockFilter{code: bpfRetK, k: action},
+			)
+		}
+	}
+	prog = append(prog, sockFilter{code: bpfRetK, k: def})
+	if len(prog) > 4096 { // BPF_MAXINSNS
+		return nil, fmt.Errorf("filter of %d instructions is too long", len(prog))
+	}
+	b := make([]byte, 8*len(prog))
+	for i, f := range prog {
+		binary.LittleEndian.PutUint16(b[8*i:], f.code)
+		b[8*i+2], b[8*i+3] = f.jt, f.jf
+		binary.LittleEndian.PutUint32(b[8*i+4:], f.k)
+	}
+	return b, nil
+}
+
+// installSeccomp installs the filter passed by Seccomp on the current
+// thread, which must have no_new_privs set.
+func installSeccomp(encoded string) error {
+	filter, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(filter) == 0 || len(filter)%8 != 0 {
+		return errors.New("invalid filter")
+	}
+	prog := struct {
+		len    uint16
+		filter unsafe.Pointer
+	}{uint16(len(filter) / 8), unsafe.Pointer(&filter[0])}
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prSetSeccomp, seccompModeFilter, uintptr(unsafe.Pointer(&prog)), 0, 0, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+const (
+	prSetSeccomp      = 22
+	seccompModeFilter = 2
+)
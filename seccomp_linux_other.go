@@ -0,0 +1,10 @@
+//go:build linux && !amd64 && !arm64
+// +build linux,!amd64,!arm64
+
+package exec
+
+// seccompArch and seccompSyscalls are not known for this architecture,
+// so Seccomp is not supported.
+const seccompArch = 0
+
+var seccompSyscalls map[string]uint32
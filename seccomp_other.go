@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package exec
+
+// Seccomp runs the child with the system call filter profile. It is
+// only supported on Linux.
+func Seccomp(profile *SeccompProfile) func(*Cmd) error {
+	return Describe("Seccomp", []Param{{"profile", profile}}, func(c *Cmd) error {
+		return notSupported("Seccomp")
+	})
+}
@@ -0,0 +1,62 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+var reexec struct {
+	sync.Mutex
+	handlers map[string]func()
+}
+
+// RegisterReexec registers fn as the handler for the role name, run by
+// Reexec when the process has been started in that role by Self. It
+// panics if a handler is already registered for name. It is usually
+// called from an init function.
+func RegisterReexec(name string, fn func()) {
+	reexec.Lock()
+	defer reexec.Unlock()
+	if _, ok := reexec.handlers[name]; ok {
+		panic(fmt.Sprintf("exec: reexec handler %q already registered", name))
+	}
+	if reexec.handlers == nil {
+		reexec.handlers = make(map[string]func())
+	}
+	reexec.handlers[name] = fn
+}
+
+// Reexec runs the handler registered for the role the process was
+// started in by Self, if any, reporting whether it did. It should be
+// called at the start of main, which should return if it reports true.
+//
+//	func main() {
+//		if exec.Reexec() {
+//			return
+//		}
+//		...
+//	}
+func Reexec() bool {
+	reexec.Lock()
+	fn, ok := reexec.handlers[os.Args[0]]
+	reexec.Unlock()
+	if ok {
+		fn()
+	}
+	return ok
+}
+
+// Self returns a Cmd which runs the current executable in the role
+// args[0], with the rest of args as its arguments, for example to do
+// privileged or namespaced work in a child. The role is passed as the
+// child's argv[0], as in the reexec package used by Docker.
+func Self(args ...string) *Cmd {
+	path, err := os.Executable()
+	c := &Cmd{Cmd: &exec.Cmd{Path: path, Args: args, Err: err}, initalised: true}
+	if len(args) == 0 {
+		c.Args = []string{path}
+	}
+	return c
+}
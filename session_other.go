@@ -0,0 +1,13 @@
+//go:build windows || plan9 || js || wasip1
+// +build windows plan9 js wasip1
+
+package exec
+
+// NewSession runs the child in a new session, detaching it from the
+// controlling terminal. It is not supported on Windows, Plan 9, js or
+// wasip1.
+func NewSession() func(*Cmd) error {
+	return Describe("NewSession", nil, func(*Cmd) error {
+		return notSupported("NewSession")
+	})
+}
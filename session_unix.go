@@ -0,0 +1,30 @@
+//go:build !windows && !plan9 && !js && !wasip1
+// +build !windows,!plan9,!js,!wasip1
+
+package exec
+
+import (
+	"errors"
+	"syscall"
+)
+
+// NewSession runs the child in a new session, detaching it from the
+// controlling terminal so it survives the terminal hanging up. The child
+// also leads a new process group, so NewSession cannot be combined with
+// SysProcAttr.Setpgid. A pseudo terminal may be made the controlling
+// terminal of the new session by setting SysProcAttr.Setctty.
+func NewSession() func(*Cmd) error {
+	return Describe("NewSession", nil, func(c *Cmd) error {
+		if c.SysProcAttr == nil {
+			c.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		c.SysProcAttr.Setsid = true
+		c.starting = append(c.starting, func(c *Cmd) error {
+			if c.SysProcAttr.Setpgid {
+				return errors.New("exec: NewSession cannot be used with Setpgid")
+			}
+			return nil
+		})
+		return nil
+	})
+}
@@ -0,0 +1,100 @@
+package exec
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrUnhealthy is returned by the run of a Sidecar's command which was
+// killed because it failed its health check.
+var ErrUnhealthy = errors.New("exec: health check failed")
+
+// Sidecar keeps a companion process, such as a local proxy a service
+// depends on, running and healthy. It is a Supervisor which also calls
+// Check periodically while the command runs, killing and restarting the
+// command, with backoff, once Check fails several times in a row.
+// A Sidecar must not be copied after first use.
+type Sidecar struct {
+	Supervisor
+
+	// Check reports whether the command is healthy, for example the
+	// result of ProbeTCP, or a function running a command.
+	Check func() error
+
+	// Interval is the time between health checks, 10s by default.
+	Interval time.Duration
+
+	// Failures is the number of consecutive failed health checks after
+	// which the command is restarted, 3 by default.
+	Failures int
+
+	mu       sync.Mutex
+	healthy  bool
+	checkErr error
+}
+
+// Start starts the command, the supervisor and the health checks.
+func (s *Sidecar) Start() error {
+	if s.Check == nil {
+		return errors.New("exec: Sidecar requires a Check")
+	}
+	if err := s.Supervisor.Start(); err != nil {
+		return err
+	}
+	go s.check()
+	return nil
+}
+
+// Healthy reports whether the last health check of the current run of
+// the command succeeded, and if not, the error from the last check.
+func (s *Sidecar) Healthy() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.healthy, s.checkErr
+}
+
+func (s *Sidecar) check() {
+	interval, failures := s.Interval, s.Failures
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	if failures <= 0 {
+		failures = 3
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	restarts, failed := s.Restarts(), 0
+	for {
+		select {
+		case <-s.Supervisor.done:
+			return
+		case <-t.C:
+		}
+		if s.State() != Running {
+			continue
+		}
+		if r := s.Restarts(); r != restarts {
+			// a new run of the command, whose health is unknown.
+			restarts, failed = r, 0
+			s.mu.Lock()
+			s.healthy, s.checkErr = false, nil
+			s.mu.Unlock()
+		}
+		err := s.Check()
+		s.mu.Lock()
+		s.healthy, s.checkErr = err == nil, err
+		s.mu.Unlock()
+		if err == nil {
+			failed = 0
+			continue
+		}
+		if failed++; failed >= failures {
+			failed = 0
+			s.mu.Lock()
+			s.healthy = false
+			s.mu.Unlock()
+			s.kill(ErrUnhealthy)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package exec
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrAlreadyRunning is returned by Start when the lock taken by
+// SingleInstance is held by another process.
+var ErrAlreadyRunning = errors.New("exec: another instance is running")
+
+// SingleInstance takes an exclusive lock on the file at lockPath,
+// creating it if need be, before starting the command, so that only one
+// instance of a job, such as one run by cron, runs at a time. If the
+// lock is held by another process, Start returns ErrAlreadyRunning. The
+// lock is held by the current process, not the child, and released once
+// the command has exited. On AIX and Solaris the lock is a POSIX record
+// lock, which does not exclude other instances within the same process.
+// It is not supported on Plan 9, js or wasip1.
+func SingleInstance(lockPath string) func(*Cmd) error {
+	return Describe("SingleInstance", []Param{{"lockPath", lockPath}}, singleInstance(lockPath, false))
+}
+
+// SingleInstanceWait is like SingleInstance, but waits for the lock to
+// be released rather than failing.
+func SingleInstanceWait(lockPath string) func(*Cmd) error {
+	return Describe("SingleInstanceWait", []Param{{"lockPath", lockPath}}, singleInstance(lockPath, true))
+}
+
+func singleInstance(lockPath string, wait bool) func(*Cmd) error {
+	return func(c *Cmd) error {
+		var lock *os.File
+		c.starting = append(c.starting, func(c *Cmd) error {
+			f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0644)
+			if err != nil {
+				return err
+			}
+			if err := lockFile(f, wait); err != nil {
+				f.Close()
+				return err
+			}
+			lock = f
+			return nil
+		})
+		c.finished = append(c.finished, func(*Cmd) error {
+			// closing the file releases the lock.
+			return lock.Close()
+		})
+		return nil
+	}
+}
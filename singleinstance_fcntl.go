@@ -0,0 +1,33 @@
+//go:build aix || solaris
+// +build aix solaris
+
+package exec
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock on f, returning ErrAlreadyRunning if
+// it is held elsewhere and wait is false. flock is not available here,
+// so the whole file is locked with fcntl instead.
+func lockFile(f *os.File, wait bool) error {
+	cmd := syscall.F_SETLK
+	if wait {
+		cmd = syscall.F_SETLKW
+	}
+	lk := syscall.Flock_t{Type: syscall.F_WRLCK, Whence: io.SeekStart}
+	for {
+		err := syscall.FcntlFlock(f.Fd(), cmd, &lk)
+		switch err {
+		case nil:
+			return nil
+		case syscall.EINTR:
+			continue
+		case syscall.EAGAIN, syscall.EACCES:
+			return ErrAlreadyRunning
+		}
+		return &os.PathError{Op: "fcntl", Path: f.Name(), Err: err}
+	}
+}
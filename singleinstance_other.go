@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !aix && !solaris && !windows
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!aix,!solaris,!windows
+
+package exec
+
+import "os"
+
+func lockFile(*os.File, bool) error {
+	return notSupported("SingleInstance")
+}
@@ -0,0 +1,30 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package exec
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock on f, returning ErrAlreadyRunning if
+// it is held elsewhere and wait is false.
+func lockFile(f *os.File, wait bool) error {
+	how := syscall.LOCK_EX
+	if !wait {
+		how |= syscall.LOCK_NB
+	}
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+		switch err {
+		case nil:
+			return nil
+		case syscall.EINTR:
+			continue
+		case syscall.EWOULDBLOCK:
+			return ErrAlreadyRunning
+		}
+		return &os.PathError{Op: "flock", Path: f.Name(), Err: err}
+	}
+}
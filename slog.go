@@ -0,0 +1,58 @@
+//go:build go1.21
+// +build go1.21
+
+package exec
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Slog logs the command to logger, or slog.Default if logger is nil,
+// with a record at level once it has started and another once it has
+// exited, or a record at slog.LevelError if it failed. The records have
+// the attributes cmd, args (as returned by RedactedArgs), dir, pid, and,
+// once the command has exited, exit_code, duration_ms and, if it failed,
+// error.
+func Slog(logger *slog.Logger, level slog.Level) func(*Cmd) error {
+	return Describe("Slog", []Param{{"logger", logger}, {"level", level}}, func(c *Cmd) error {
+		if logger == nil {
+			logger = slog.Default()
+		}
+		var attrs []slog.Attr
+		c.started = append(c.started, func(c *Cmd) error {
+			attrs = []slog.Attr{
+				slog.String("cmd", c.Redact(c.Path)),
+				slog.Any("args", c.RedactedArgs()),
+				slog.String("dir", c.Dir),
+				slog.Int("pid", c.Process.Pid),
+			}
+			logger.LogAttrs(context.Background(), level, "exec: start", attrs...)
+			return nil
+		})
+		c.finished = append(c.finished, func(c *Cmd) error {
+			if attrs == nil {
+				// the command did not start.
+				attrs = []slog.Attr{
+					slog.String("cmd", c.Redact(c.Path)),
+					slog.Any("args", c.RedactedArgs()),
+					slog.String("dir", c.Dir),
+				}
+			}
+			if c.ProcessState != nil {
+				attrs = append(attrs,
+					slog.Int("exit_code", c.ProcessState.ExitCode()),
+					slog.Int64("duration_ms", c.exitTime.Sub(c.startTime).Milliseconds()),
+				)
+			}
+			if err := c.outcome(); err != nil {
+				attrs = append(attrs, slog.String("error", c.Redact(err.Error())))
+				logger.LogAttrs(context.Background(), slog.LevelError, "exec: error", attrs...)
+				return nil
+			}
+			logger.LogAttrs(context.Background(), level, "exec: finish", attrs...)
+			return nil
+		})
+		return nil
+	})
+}
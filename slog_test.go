@@ -0,0 +1,47 @@
+//go:build go1.21
+// +build go1.21
+
+package exec_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/pkg/exec"
+)
+
+func TestSlog(t *testing.T) {
+	var b bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&b, nil))
+	helperCommand(t, "echo", "--token", "hunter2").Run(exec.Slog(logger, slog.LevelInfo))
+	helperCommand(t, "exit", "3").Run(exec.Slog(logger, slog.LevelDebug))
+
+	var records []map[string]interface{}
+	dec := json.NewDecoder(&b)
+	for dec.More() {
+		var r map[string]interface{}
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 3 {
+		t.Fatalf("want start and finish records for echo, and an error record for exit; got %v", records)
+	}
+	start, finish, failed := records[0], records[1], records[2]
+	if start["msg"] != "exec: start" || start["pid"] == nil {
+		t.Errorf("start: got %v", start)
+	}
+	args, _ := start["args"].([]interface{})
+	if len(args) < 2 || args[len(args)-2] != "--token" || args[len(args)-1] != "REDACTED" {
+		t.Errorf("start: want the token redacted, got %v", start["args"])
+	}
+	if finish["msg"] != "exec: finish" || finish["level"] != "INFO" || finish["exit_code"] != 0.0 || finish["duration_ms"] == nil {
+		t.Errorf("finish: got %v", finish)
+	}
+	if failed["msg"] != "exec: error" || failed["level"] != "ERROR" || failed["exit_code"] != 3.0 || failed["error"] != "exit status 3" {
+		t.Errorf("error: got %v", failed)
+	}
+}
@@ -0,0 +1,17 @@
+package exec
+
+// Spec describes a command which may be run many times. Unlike Cmd,
+// which cannot be reused, each call to Command returns a fresh Cmd.
+type Spec struct {
+	Name string             // program to run
+	Args []string           // arguments, not including Name
+	Opts []func(*Cmd) error // options applied to each Cmd at Start
+}
+
+// Command returns a Cmd to execute the command described by s.
+// The options in s.Opts are applied before any passed to Start.
+func (s Spec) Command() *Cmd {
+	c := Command(s.Name, s.Args...)
+	c.opts = s.Opts
+	return c
+}
@@ -0,0 +1,226 @@
+package exec
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// jsonSpec is the JSON form of a Spec.
+type jsonSpec struct {
+	Name string       `json:"name"`
+	Args []string     `json:"args,omitempty"`
+	Opts []jsonOption `json:"options,omitempty"`
+}
+
+type jsonOption struct {
+	Name string            `json:"name"`
+	Args []json.RawMessage `json:"args,omitempty"`
+}
+
+// portableOptions returns the options which may be sent across the wire
+// as part of a Spec, by name, given their JSON encoded arguments.
+// Options which refer to values in the current process, such as
+// writers, callbacks and open files, are deliberately absent, as are
+// those which would let whoever sent the Spec write files, change the
+// root directory or take the terminal of the process which runs it.
+var portableOptions = map[string]func(args []json.RawMessage) (func(*Cmd) error, error){
+	"Dir":             stringOption(Dir),
+	"Unsetenv":        stringOption(Unsetenv),
+	"Argv0":           stringOption(Argv0),
+	"Locale":          stringOption(Locale),
+	"Timezone":        stringOption(Timezone),
+	"PrefixOutput":    stringOption(PrefixOutput),
+	"TimestampOutput": stringOption(TimestampOutput),
+
+	"IdleTimeout":     durationOption(IdleTimeout),
+	"CPULimit":        durationOption(CPULimit),
+	"MaxOutput":       int64Option(MaxOutput),
+	"MaxOutputStrict": int64Option(MaxOutputStrict),
+	"DiskQuota":       int64Option(DiskQuota),
+	"NetRateLimit":    int64Option(NetRateLimit),
+	"TailLines":       intOption(TailLines),
+	"Nice":            intOption(Nice),
+	"OOMScoreAdj":     intOption(OOMScoreAdj),
+
+	"NewSession":        noArgOption(NewSession),
+	"HideWindow":        noArgOption(HideWindow),
+	"NormalizeNewlines": noArgOption(NormalizeNewlines),
+	"Correlate":         noArgOption(Correlate),
+	"PrefixOutputPID":   noArgOption(PrefixOutputPID),
+	"ExpandArgs":        noArgOption(ExpandArgs),
+	"ExpandGlobs":       noArgOption(ExpandGlobs),
+	"ExpandTilde":       noArgOption(ExpandTilde),
+
+	"RedactEnv":  stringsOption(RedactEnv),
+	"RedactArgs": stringsOption(RedactArgs),
+
+	"Setenv": func(args []json.RawMessage) (func(*Cmd) error, error) {
+		var key, val string
+		err := decodeArgs(args, &key, &val)
+		return Setenv(key, val), err
+	},
+	"Limit": func(args []json.RawMessage) (func(*Cmd) error, error) {
+		var resource Resource
+		var soft, hard uint64
+		err := decodeArgs(args, &resource, &soft, &hard)
+		return Limit(resource, soft, hard), err
+	},
+	"Priority": func(args []json.RawMessage) (func(*Cmd) error, error) {
+		var class PriorityClass
+		err := decodeArgs(args, &class)
+		return Priority(class), err
+	},
+	"Cgroup": func(args []json.RawMessage) (func(*Cmd) error, error) {
+		var cfg CgroupConfig
+		err := decodeArgs(args, &cfg)
+		return Cgroup(cfg), err
+	},
+	"ExpandArgsFrom": func(args []json.RawMessage) (func(*Cmd) error, error) {
+		var vars map[string]string
+		err := decodeArgs(args, &vars)
+		return ExpandArgsFrom(vars), err
+	},
+	"Verify": func(args []json.RawMessage) (func(*Cmd) error, error) {
+		var checksums map[string]string
+		err := decodeArgs(args, &checksums)
+		return Verify(checksums), err
+	},
+}
+
+func noArgOption(fn func() func(*Cmd) error) func([]json.RawMessage) (func(*Cmd) error, error) {
+	return func(args []json.RawMessage) (func(*Cmd) error, error) {
+		err := decodeArgs(args)
+		return fn(), err
+	}
+}
+
+func stringOption(fn func(string) func(*Cmd) error) func([]json.RawMessage) (func(*Cmd) error, error) {
+	return func(args []json.RawMessage) (func(*Cmd) error, error) {
+		var s string
+		err := decodeArgs(args, &s)
+		return fn(s), err
+	}
+}
+
+func stringsOption(fn func(...string) func(*Cmd) error) func([]json.RawMessage) (func(*Cmd) error, error) {
+	return func(args []json.RawMessage) (func(*Cmd) error, error) {
+		var s []string
+		err := decodeArgs(args, &s)
+		return fn(s...), err
+	}
+}
+
+func intOption(fn func(int) func(*Cmd) error) func([]json.RawMessage) (func(*Cmd) error, error) {
+	return func(args []json.RawMessage) (func(*Cmd) error, error) {
+		var n int
+		err := decodeArgs(args, &n)
+		return fn(n), err
+	}
+}
+
+func int64Option(fn func(int64) func(*Cmd) error) func([]json.RawMessage) (func(*Cmd) error, error) {
+	return func(args []json.RawMessage) (func(*Cmd) error, error) {
+		var n int64
+		err := decodeArgs(args, &n)
+		return fn(n), err
+	}
+}
+
+func durationOption(fn func(time.Duration) func(*Cmd) error) func([]json.RawMessage) (func(*Cmd) error, error) {
+	return func(args []json.RawMessage) (func(*Cmd) error, error) {
+		var d time.Duration
+		err := decodeArgs(args, &d)
+		return fn(d), err
+	}
+}
+
+// decodeArgs decodes args into ptrs, in order. Durations are encoded as
+// strings, as formatted by time.Duration.String.
+func decodeArgs(args []json.RawMessage, ptrs ...interface{}) error {
+	if len(args) != len(ptrs) {
+		return fmt.Errorf("want %d arguments, got %d", len(ptrs), len(args))
+	}
+	for i, p := range ptrs {
+		if d, ok := p.(*time.Duration); ok {
+			var s string
+			if err := json.Unmarshal(args[i], &s); err != nil {
+				return err
+			}
+			var err error
+			if *d, err = time.ParseDuration(s); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := json.Unmarshal(args[i], p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes s as JSON, so that it may be queued or sent to
+// another process, which runs it after decoding it with UnmarshalJSON.
+// Only options which do not refer to values in the current process may
+// be encoded: those which set the directory, environment, locale and
+// argv[0], limit resources, time or output, change the priority, run
+// the child in a new session or cgroup, decorate its output lines,
+// redact secrets, or verify the program. Any other option in s.Opts is
+// an error.
+func (s Spec) MarshalJSON() ([]byte, error) {
+	js := jsonSpec{Name: s.Name, Args: s.Args}
+	c := Command(s.Name, s.Args...)
+	c.describing = true
+	for _, opt := range s.Opts {
+		n := len(c.applied)
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+		if len(c.applied) == n {
+			return nil, fmt.Errorf("exec: cannot encode an option not created by this package")
+		}
+	}
+	for _, o := range c.applied {
+		if _, ok := portableOptions[o.Name]; !ok {
+			return nil, fmt.Errorf("exec: cannot encode option %s", o)
+		}
+		jo := jsonOption{Name: o.Name}
+		for _, p := range o.Params {
+			v := p.Value
+			if d, ok := v.(time.Duration); ok {
+				v = d.String()
+			}
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("exec: cannot encode option %s: %v", o, err)
+			}
+			jo.Args = append(jo.Args, b)
+		}
+		js.Opts = append(js.Opts, jo)
+	}
+	return json.Marshal(js)
+}
+
+// UnmarshalJSON decodes a Spec encoded by MarshalJSON, recreating its
+// options.
+func (s *Spec) UnmarshalJSON(b []byte) error {
+	var js jsonSpec
+	if err := json.Unmarshal(b, &js); err != nil {
+		return err
+	}
+	spec := Spec{Name: js.Name, Args: js.Args}
+	for _, jo := range js.Opts {
+		decode, ok := portableOptions[jo.Name]
+		if !ok {
+			return fmt.Errorf("exec: unknown option %q", jo.Name)
+		}
+		opt, err := decode(jo.Args)
+		if err != nil {
+			return fmt.Errorf("exec: option %s: %v", jo.Name, err)
+		}
+		spec.Opts = append(spec.Opts, opt)
+	}
+	*s = spec
+	return nil
+}
@@ -0,0 +1,171 @@
+package exec
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// SpillBuffer is a buffer which holds up to a threshold of bytes in
+// memory, spilling its contents to a temporary file once that is
+// exceeded. Data written to a SpillBuffer may be read back with Read
+// and Seek, independently of further writes.
+// The buffer must be closed to remove the temporary file.
+type SpillBuffer struct {
+	threshold int64
+
+	mu     sync.Mutex
+	buf    []byte
+	f      *os.File // nil until the threshold is exceeded
+	size   int64
+	off    int64 // read offset
+	closed bool
+}
+
+// NewSpillBuffer returns a SpillBuffer which holds up to threshold bytes
+// in memory.
+func NewSpillBuffer(threshold int64) *SpillBuffer {
+	return &SpillBuffer{threshold: threshold}
+}
+
+// Write appends p to the buffer, creating the temporary file if the
+// buffer grows beyond its threshold.
+func (b *SpillBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, os.ErrClosed
+	}
+	if b.f == nil && b.size+int64(len(p)) <= b.threshold {
+		b.buf = append(b.buf, p...)
+		b.size += int64(len(p))
+		return len(p), nil
+	}
+	if b.f == nil {
+		f, err := ioutil.TempFile("", "exec-output-")
+		if err != nil {
+			return 0, err
+		}
+		if _, err := f.Write(b.buf); err != nil {
+			f.Close()
+			os.Remove(f.Name())
+			return 0, err
+		}
+		b.f, b.buf = f, nil
+	}
+	n, err := b.f.WriteAt(p, b.size)
+	b.size += int64(n)
+	return n, err
+}
+
+// Read reads from the buffer at the current read offset.
+func (b *SpillBuffer) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, os.ErrClosed
+	}
+	if b.off >= b.size {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > b.size-b.off {
+		p = p[:b.size-b.off]
+	}
+	var n int
+	var err error
+	if b.f == nil {
+		n = copy(p, b.buf[b.off:])
+	} else {
+		n, err = b.f.ReadAt(p, b.off)
+		if err == io.EOF && n == len(p) {
+			err = nil
+		}
+	}
+	b.off += int64(n)
+	return n, err
+}
+
+// Seek sets the read offset, see io.Seeker.
+func (b *SpillBuffer) Seek(offset int64, whence int) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, os.ErrClosed
+	}
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += b.off
+	case io.SeekEnd:
+		offset += b.size
+	default:
+		return 0, errors.New("exec: SpillBuffer.Seek: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("exec: SpillBuffer.Seek: negative position")
+	}
+	b.off = offset
+	return offset, nil
+}
+
+// Size returns the number of bytes written to the buffer.
+func (b *SpillBuffer) Size() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size
+}
+
+// Spilled reports whether the buffer has spilled to a temporary file.
+func (b *SpillBuffer) Spilled() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.f != nil
+}
+
+// Bytes returns the contents of the buffer, reading them from the
+// temporary file if the buffer has spilled.
+func (b *SpillBuffer) Bytes() ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, os.ErrClosed
+	}
+	if b.f == nil {
+		return append([]byte(nil), b.buf...), nil
+	}
+	p := make([]byte, b.size)
+	_, err := b.f.ReadAt(p, 0)
+	return p, err
+}
+
+// Close releases the buffer, removing the temporary file if there is one.
+// Once it is closed, reading from or writing to the buffer returns
+// os.ErrClosed.
+func (b *SpillBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf, b.closed = nil, true
+	if b.f == nil {
+		return nil
+	}
+	err := b.f.Close()
+	if errRemove := os.Remove(b.f.Name()); err == nil {
+		err = errRemove
+	}
+	b.f = nil
+	return err
+}
+
+// SpillOutput runs the command and returns its standard output in a
+// SpillBuffer which holds up to threshold bytes in memory, making it
+// safe to use with commands whose output may be very large. The buffer
+// is returned, positioned at the start, even if the command fails.
+// The caller must Close it.
+func (c *Cmd) SpillOutput(threshold int64, opts ...func(*Cmd) error) (*SpillBuffer, error) {
+	b := NewSpillBuffer(threshold)
+	opts = append([]func(*Cmd) error{Stdout(b)}, opts...)
+	err := c.Run(opts...)
+	return b, err
+}
@@ -0,0 +1,72 @@
+// Package ssh runs commands on remote hosts with the ssh client, as a
+// backend for package exec, so that they may be run with most of the
+// same options as local ones, see exec.BackendCommand.
+package ssh
+
+import (
+	"strings"
+
+	"github.com/pkg/exec"
+)
+
+// Host is an exec.Backend which runs commands on a remote host with
+// ssh. The remote user's login shell must be a POSIX shell. A Spec may be
+// run on a host with exec.RemoteSpec, so that its options are applied
+// there by an agent program.
+type Host struct {
+	Name    string   // host as ssh accepts it, such as "user@example.com" or an alias from ~/.ssh/config
+	Options []string // further arguments to ssh, such as "-p", "2222"
+}
+
+// Command returns a Cmd to run the named program with the given
+// arguments on host, see exec.BackendCommand.
+//
+//	out, err := ssh.Command("build@ci", "uname", "-r").Output(exec.Dir("/srv"))
+func Command(host, name string, args ...string) *exec.Cmd {
+	return (&Host{Name: host}).Command(name, args...)
+}
+
+// Command returns a Cmd to run the named program with the given
+// arguments on h.
+func (h *Host) Command(name string, args ...string) *exec.Cmd {
+	return exec.BackendCommand(h, name, args...)
+}
+
+// Wrap returns the ssh command line which runs r on h. A terminal is
+// allocated on the remote host if stdin is a terminal.
+func (h *Host) Wrap(r *exec.RemoteCommand) ([]string, error) {
+	argv := append([]string{"ssh"}, h.Options...)
+	if r.TTY {
+		argv = append(argv, "-t")
+	} else {
+		argv = append(argv, "-T")
+	}
+	return append(argv, "--", h.Name, shellCommand(r)), nil
+}
+
+// shellCommand returns the POSIX shell command which runs r.
+func shellCommand(r *exec.RemoteCommand) string {
+	var words []string
+	if r.Dir != "" {
+		words = append(words, "cd", quote(r.Dir), "&&")
+	}
+	for _, key := range r.Unset {
+		words = append(words, "unset", quote(key), "&&")
+	}
+	words = append(words, "exec")
+	if len(r.Env) > 0 {
+		words = append(words, "env")
+		for _, kv := range r.Env {
+			words = append(words, quote(kv))
+		}
+	}
+	for _, arg := range r.Args {
+		words = append(words, quote(arg))
+	}
+	return strings.Join(words, " ")
+}
+
+// quote quotes s for use as a single word by a POSIX shell.
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,55 @@
+package ssh_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/pkg/exec"
+	"github.com/pkg/exec/ssh"
+)
+
+func TestWrap(t *testing.T) {
+	h := &ssh.Host{Name: "build@ci", Options: []string{"-p", "2222"}}
+	argv, err := h.Wrap(&exec.RemoteCommand{
+		Args:  []string{"echo", "it's"},
+		Dir:   "/srv/my app",
+		Env:   []string{"A=1"},
+		Unset: []string{"B"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"ssh", "-p", "2222", "-T", "--", "build@ci", `cd '/srv/my app' && unset 'B' && exec env 'A=1' 'echo' 'it'\''s'`}
+	if strings.Join(argv, "|") != strings.Join(want, "|") {
+		t.Errorf("want %q, got %q", want, argv)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("skipping test; requires /bin/sh")
+	}
+	// an ssh which runs the command locally.
+	bin := t.TempDir()
+	script := "#!/bin/sh\nwhile [ \"$1\" != -- ]; do shift; done\nexec /bin/sh -c \"$3\"\n"
+	if err := ioutil.WriteFile(filepath.Join(bin, "ssh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	t.Setenv("FAREWELL", "bye")
+
+	dir := t.TempDir()
+	out, err := ssh.Command("build@ci", "sh", "-c", `echo "$PWD $GREETING ${FAREWELL-unset}"`).Output(exec.Dir(dir), exec.Setenv("GREETING", "hello"), exec.Unsetenv("FAREWELL"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := dir + " hello unset\n"; string(out) != want {
+		t.Errorf("want %q, got %q", want, out)
+	}
+}
@@ -0,0 +1,41 @@
+package exec
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// Stop asks the command to exit by sending it sig, waiting up to grace
+// for it to do so before killing it. Stop reports whether the command
+// exited within the grace period.
+//
+// Stop waits for the command to exit and returns the error from Wait,
+// so Wait must not be called on a stopped command. If sig cannot be
+// delivered, for example os.Interrupt on Windows, the command is killed
+// immediately.
+func (c *Cmd) Stop(sig os.Signal, grace time.Duration) (bool, error) {
+	if c.Process == nil {
+		return false, errors.New("exec: not started")
+	}
+	done := make(chan error, 1)
+	go func() { done <- c.Wait() }()
+	return c.stop(sig, grace, done)
+}
+
+// stop implements Stop, done receives the result of Wait.
+func (c *Cmd) stop(sig os.Signal, grace time.Duration, done <-chan error) (bool, error) {
+	if err := c.Process.Signal(sig); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		c.Process.Kill()
+		return false, <-done
+	}
+	expired, stop := after(c.clk(), grace)
+	defer stop()
+	select {
+	case err := <-done:
+		return true, err
+	case <-expired:
+		c.Process.Kill()
+		return false, <-done
+	}
+}
@@ -0,0 +1,313 @@
+package exec
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// State is the state of a supervised command.
+type State int
+
+const (
+	Starting State = iota // the command is being started
+	Running               // the command is running
+	Backoff               // the command exited and is waiting to be restarted
+	Stopped               // the supervisor was stopped
+	Failed                // the restart budget was exhausted
+	Exited                // the command exited and the restart policy did not restart it
+)
+
+var stateNames = [...]string{"starting", "running", "backoff", "stopped", "failed", "exited"}
+
+func (s State) String() string {
+	if s < 0 || int(s) >= len(stateNames) {
+		return "unknown"
+	}
+	return stateNames[s]
+}
+
+// RestartPolicy controls when a Supervisor restarts its command.
+type RestartPolicy int
+
+const (
+	RestartAlways    RestartPolicy = iota // restart whenever the command exits
+	RestartOnFailure                      // restart only if the command exits with an error
+	RestartNever                          // never restart the command
+)
+
+// restart reports whether a command which exited with err should be
+// restarted under policy r.
+func (r RestartPolicy) restart(err error) bool {
+	switch r {
+	case RestartOnFailure:
+		return err != nil
+	case RestartNever:
+		return false
+	}
+	return true
+}
+
+// Supervisor keeps a command running, restarting it with exponential
+// backoff whenever it exits.
+// A Supervisor must not be copied after first use.
+type Supervisor struct {
+	Spec Spec // the command to supervise
+
+	// MinBackoff and MaxBackoff bound the delay before a restart. The
+	// delay doubles after each consecutive exit, and is reset once the
+	// command has run for longer than MaxBackoff. The defaults are
+	// 100ms and 30s.
+	MinBackoff, MaxBackoff time.Duration
+
+	// MaxRestarts is the number of times the command will be restarted
+	// before the supervisor gives up. If zero, there is no limit.
+	MaxRestarts int
+
+	// Restart controls whether the command is restarted when it exits.
+	// The default is RestartAlways.
+	Restart RestartPolicy
+
+	// StopSignal and StopGrace control how Stop shuts the command down,
+	// see Cmd.Stop. The defaults are os.Interrupt and 10s.
+	StopSignal os.Signal
+	StopGrace  time.Duration
+
+	// OnStateChange, if non nil, is called on each state transition with
+	// the new state and, for Backoff, Stopped, Failed, and Exited, the
+	// error returned by the last run of the command.
+	OnStateChange func(State, error)
+
+	// Clock, if non nil, is used for the backoff between restarts and by
+	// each run of the command, see UseClock.
+	Clock Clock
+
+	mu       sync.Mutex
+	state    State
+	restarts int
+	err      error
+	failures []error // of each run, if MaxRestarts is set
+	graceful bool    // the command exited within StopGrace when stopped
+	cmd      *Cmd    // the current run of the command
+	stopc    chan struct{}
+	done     chan struct{}
+}
+
+// Supervise starts a Supervisor for spec with the default settings.
+func Supervise(spec Spec) (*Supervisor, error) {
+	s := &Supervisor{Spec: spec}
+	return s, s.Start()
+}
+
+// Start starts the command and the supervisor. An error is returned if
+// the command could not be started the first time.
+func (s *Supervisor) Start() error {
+	s.mu.Lock()
+	if s.done != nil {
+		s.mu.Unlock()
+		return errors.New("exec: Supervisor already started")
+	}
+	s.stopc = make(chan struct{})
+	s.done = make(chan struct{})
+	s.mu.Unlock()
+
+	cmd, done, err := s.start()
+	if err != nil {
+		s.finish(Failed, err)
+		return err
+	}
+	go s.run(cmd, done)
+	return nil
+}
+
+// Stop stops the command and waits for the supervisor to exit.
+func (s *Supervisor) Stop() error {
+	s.mu.Lock()
+	if s.done == nil {
+		s.mu.Unlock()
+		return errors.New("exec: Supervisor not started")
+	}
+	select {
+	case <-s.stopc:
+	default:
+		close(s.stopc)
+	}
+	s.mu.Unlock()
+	<-s.done
+	return nil
+}
+
+// Wait waits for the supervisor to exit, either because Stop was called,
+// the restart budget was exhausted, or the restart policy did not
+// restart the command. In the latter cases the error from the last run
+// of the command is returned, except that if the restart budget was
+// exhausted a MultiError of the errors from every run is returned.
+func (s *Supervisor) Wait() error {
+	s.mu.Lock()
+	done := s.done
+	s.mu.Unlock()
+	if done == nil {
+		return errors.New("exec: Supervisor not started")
+	}
+	<-done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch s.state {
+	case Stopped:
+		return nil
+	case Failed:
+		if len(s.failures) > 0 {
+			return joinErrors(s.failures)
+		}
+	}
+	return s.err
+}
+
+// State returns the current state of the supervised command.
+func (s *Supervisor) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// Restarts returns the number of times the command has been restarted.
+func (s *Supervisor) Restarts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.restarts
+}
+
+func (s *Supervisor) start() (*Cmd, <-chan error, error) {
+	s.setState(Starting, nil)
+	cmd := s.Spec.Command()
+	var opts []func(*Cmd) error
+	if s.Clock != nil {
+		opts = append(opts, UseClock(s.Clock))
+	}
+	if err := cmd.Start(opts...); err != nil {
+		return nil, nil, err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	s.mu.Lock()
+	s.cmd = cmd
+	s.mu.Unlock()
+	s.setState(Running, nil)
+	return cmd, done, nil
+}
+
+func (s *Supervisor) run(cmd *Cmd, done <-chan error) {
+	min, max := s.MinBackoff, s.MaxBackoff
+	if min <= 0 {
+		min = 100 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 30 * time.Second
+	}
+	clk := s.Clock
+	if clk == nil {
+		clk = systemClock{}
+	}
+	backoff := min
+	var err error
+	for {
+		started := clk.Now()
+		if cmd != nil {
+			select {
+			case err = <-done:
+			case <-s.stopc:
+				var graceful bool
+				graceful, err = cmd.stop(s.stopSignal(), s.stopGrace(), done)
+				s.mu.Lock()
+				s.err, s.graceful = err, graceful
+				s.mu.Unlock()
+				s.finish(Stopped, err)
+				return
+			}
+		}
+		if clk.Now().Sub(started) > max {
+			backoff = min
+		}
+		if !s.Restart.restart(err) {
+			s.mu.Lock()
+			s.err = err
+			s.mu.Unlock()
+			s.finish(Exited, err)
+			return
+		}
+
+		s.mu.Lock()
+		if s.MaxRestarts > 0 && err != nil {
+			s.failures = append(s.failures, err)
+		}
+		exhausted := s.MaxRestarts > 0 && s.restarts >= s.MaxRestarts
+		if !exhausted {
+			s.restarts++
+		}
+		s.mu.Unlock()
+		if exhausted {
+			s.finish(Failed, err)
+			return
+		}
+
+		s.setState(Backoff, err)
+		expired, stop := after(clk, backoff)
+		select {
+		case <-expired:
+		case <-s.stopc:
+			stop()
+			s.mu.Lock()
+			s.err, s.graceful = err, true // the command had already exited
+			s.mu.Unlock()
+			s.finish(Stopped, err)
+			return
+		}
+		if backoff *= 2; backoff > max {
+			backoff = max
+		}
+		cmd, done, err = s.start()
+	}
+}
+
+// kill kills the running command, recording err as the reason, so it is
+// restarted according to the restart policy.
+func (s *Supervisor) kill(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == Running {
+		s.cmd.kill(err)
+	}
+}
+
+func (s *Supervisor) stopSignal() os.Signal {
+	if s.StopSignal == nil {
+		return os.Interrupt
+	}
+	return s.StopSignal
+}
+
+func (s *Supervisor) stopGrace() time.Duration {
+	if s.StopGrace <= 0 {
+		return 10 * time.Second
+	}
+	return s.StopGrace
+}
+
+func (s *Supervisor) setState(state State, err error) {
+	s.mu.Lock()
+	s.state = state
+	if err != nil {
+		s.err = err
+	}
+	fn := s.OnStateChange
+	s.mu.Unlock()
+	if fn != nil {
+		fn(state, err)
+	}
+}
+
+func (s *Supervisor) finish(state State, err error) {
+	s.setState(state, err)
+	close(s.done)
+}
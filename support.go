@@ -0,0 +1,57 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// ErrNotSupported is returned, possibly wrapped, by options which are
+// not supported on the current platform or host.
+var ErrNotSupported = errors.New("not supported")
+
+func notSupported(option string) error {
+	return fmt.Errorf("exec: %s %w on %s", option, ErrNotSupported, runtime.GOOS)
+}
+
+// BestEffort applies opt if it is supported on the current platform.
+// If opt reports ErrNotSupported, when it is applied or from the hooks it
+// registers to run once the program is resolved, as the command starts
+// or once it has started, the command runs without it, and the error is
+// recorded in the command's Warnings. A hook which reports
+// ErrNotSupported may already have configured part of the option. Any
+// other error is returned as normal.
+func BestEffort(opt func(*Cmd) error) func(*Cmd) error {
+	return Describe("BestEffort", []Param{{"opt", opt}}, func(c *Cmd) error {
+		resolved, starting, started := len(c.resolved), len(c.starting), len(c.started)
+		err := opt(c)
+		if errors.Is(err, ErrNotSupported) {
+			c.resolved, c.starting, c.started = c.resolved[:resolved], c.starting[:starting], c.started[:started]
+			c.addWarning(err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		bestEffortHooks(c.resolved[resolved:])
+		bestEffortHooks(c.starting[starting:])
+		bestEffortHooks(c.started[started:])
+		return nil
+	})
+}
+
+// bestEffortHooks replaces hooks, in place, with ones which record
+// ErrNotSupported as a warning rather than returning it.
+func bestEffortHooks(hooks []func(*Cmd) error) {
+	for i, fn := range hooks {
+		fn := fn
+		hooks[i] = func(c *Cmd) error {
+			err := fn(c)
+			if errors.Is(err, ErrNotSupported) {
+				c.addWarning(err)
+				return nil
+			}
+			return err
+		}
+	}
+}
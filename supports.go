@@ -0,0 +1,52 @@
+package exec
+
+import (
+	"bytes"
+	"regexp"
+	"sync"
+	"time"
+)
+
+var helpCache struct {
+	sync.Mutex
+	text map[string][]byte // help output by SHA-256 of the program
+}
+
+// Supports reports whether the program tool accepts flag, which may also
+// be a subcommand, by looking for it in the output of "tool --help".
+// The output is cached by the SHA-256 of the program, so each version of
+// a tool is probed once however it is named, and a tool upgraded in place
+// is probed again.
+func Supports(tool, flag string) (bool, error) {
+	path, err := LookPath(tool)
+	if err != nil {
+		return false, err
+	}
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return false, err
+	}
+	helpCache.Lock()
+	help, ok := helpCache.text[sum]
+	helpCache.Unlock()
+	if !ok {
+		var b bytes.Buffer
+		err := Command(path, "--help").Run(Stdout(&b), Stderr(&b), MaxOutput(1<<20), IdleTimeout(10*time.Second))
+		if b.Len() == 0 {
+			// many tools exit with a non zero status after printing help,
+			// so an error only matters if there is no output.
+			return false, err
+		}
+		help = b.Bytes()
+		helpCache.Lock()
+		if helpCache.text == nil {
+			helpCache.text = make(map[string][]byte)
+		}
+		helpCache.text[sum] = help
+		helpCache.Unlock()
+	}
+	// flag must not be part of a longer word, such as "-test" in
+	// "-test.run", but may end a sentence.
+	re := regexp.MustCompile(`(?m)(?:^|[^\w-])` + regexp.QuoteMeta(flag) + `(?:$|[^\w.-]|\.(?:$|\W))`)
+	return re.Match(help), nil
+}
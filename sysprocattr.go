@@ -0,0 +1,20 @@
+package exec
+
+import "syscall"
+
+// SysProcAttr calls f with the command's SysProcAttr, allocating it if
+// it is nil, so that attributes for which there is no option may be set
+// alongside the others.
+//
+//	cmd.Run(exec.SysProcAttr(func(attr *syscall.SysProcAttr) {
+//		attr.Foreground = true
+//	}))
+func SysProcAttr(f func(*syscall.SysProcAttr)) func(*Cmd) error {
+	return Describe("SysProcAttr", nil, func(c *Cmd) error {
+		if c.SysProcAttr == nil {
+			c.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		f(c.SysProcAttr)
+		return nil
+	})
+}
@@ -0,0 +1,19 @@
+//go:build plan9 || js || wasip1
+// +build plan9 js wasip1
+
+package exec
+
+// NewProcessGroup runs the child as the leader of a new process group.
+// It is not supported on Plan 9, js or wasip1.
+func NewProcessGroup() func(*Cmd) error {
+	return Describe("NewProcessGroup", nil, func(*Cmd) error {
+		return notSupported("NewProcessGroup")
+	})
+}
+
+// NoInheritHandles stops the child inheriting handles other than its
+// standard input, output and error. That is already so on Plan 9, and
+// no process can be started on js or wasip1, so it does nothing.
+func NoInheritHandles() func(*Cmd) error {
+	return Describe("NoInheritHandles", nil, func(*Cmd) error { return nil })
+}
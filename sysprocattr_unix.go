@@ -0,0 +1,26 @@
+//go:build !windows && !plan9 && !js && !wasip1
+// +build !windows,!plan9,!js,!wasip1
+
+package exec
+
+import "syscall"
+
+// NewProcessGroup runs the child as the leader of a new process group,
+// so that signals sent to the parent's group, such as SIGINT from the
+// terminal, are not delivered to it.
+func NewProcessGroup() func(*Cmd) error {
+	return Describe("NewProcessGroup", nil, func(c *Cmd) error {
+		if c.SysProcAttr == nil {
+			c.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		c.SysProcAttr.Setpgid = true
+		return nil
+	})
+}
+
+// NoInheritHandles stops the child inheriting handles other than its
+// standard input, output and error and ExtraFiles. That is already so
+// on Unix, so it does nothing.
+func NoInheritHandles() func(*Cmd) error {
+	return Describe("NoInheritHandles", nil, func(*Cmd) error { return nil })
+}
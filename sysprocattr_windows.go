@@ -0,0 +1,28 @@
+package exec
+
+import "syscall"
+
+// NewProcessGroup runs the child as the root of a new process group,
+// so that CTRL+C in the parent's console is not delivered to it.
+func NewProcessGroup() func(*Cmd) error {
+	return Describe("NewProcessGroup", nil, func(c *Cmd) error {
+		if c.SysProcAttr == nil {
+			c.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		c.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+		return nil
+	})
+}
+
+// NoInheritHandles stops the child inheriting any handles, including
+// those of its standard input, output and error, which must then be
+// left nil.
+func NoInheritHandles() func(*Cmd) error {
+	return Describe("NoInheritHandles", nil, func(c *Cmd) error {
+		if c.SysProcAttr == nil {
+			c.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		c.SysProcAttr.NoInheritHandles = true
+		return nil
+	})
+}
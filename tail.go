@@ -0,0 +1,119 @@
+package exec
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+)
+
+// TailLines keeps the last n lines written to the child's stdout and
+// stderr, which are available from Tail once the command has exited.
+// Lines longer than 64KiB are truncated, so that output without
+// newlines does not use unbounded memory. Output is still passed to any
+// writers set by Stdout or Stderr.
+func TailLines(n int) func(*Cmd) error {
+	return Describe("TailLines", []Param{{"n", n}}, func(c *Cmd) error {
+		if n <= 0 {
+			return errors.New("exec: TailLines must be positive")
+		}
+		if c.tail[0] != nil {
+			return errors.New("exec: TailLines already set")
+		}
+		c.tail = [2]*lineRing{{n: n}, {n: n}}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			i := 0
+			wrapOutput(c, func(w io.Writer) io.Writer {
+				w = io.MultiWriter(w, c.tail[i])
+				i++
+				return w
+			})
+			return nil
+		})
+		return nil
+	})
+}
+
+// Tail returns the last lines written by the child to stdout and stderr,
+// as configured by TailLines. If stdout and stderr share a writer their
+// combined output is returned as stdout.
+func (c *Cmd) Tail() (stdout, stderr []string) {
+	if c.tail[0] == nil {
+		return nil, nil
+	}
+	return c.tail[0].Lines(), c.tail[1].Lines()
+}
+
+// maxTailLine is the length at which lines kept by TailLines are
+// truncated.
+const maxTailLine = 64 << 10
+
+// lineRing retains the last n lines written to it.
+type lineRing struct {
+	n int
+
+	mu        sync.Mutex
+	lines     []string // ring of at most n lines
+	next      int      // index in lines of the oldest line, once full
+	partial   []byte   // unterminated final line, at most maxTailLine bytes
+	truncated bool     // whether bytes of partial were discarded
+}
+
+func (r *lineRing) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(p)
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			break
+		}
+		r.appendPartial(p[:i])
+		r.add(r.partialLine())
+		r.partial, r.truncated = r.partial[:0], false
+		p = p[i+1:]
+	}
+	r.appendPartial(p)
+	return n, nil
+}
+
+// appendPartial appends p to the unterminated final line, discarding
+// what would take it past maxTailLine.
+func (r *lineRing) appendPartial(p []byte) {
+	if room := maxTailLine - len(r.partial); len(p) > room {
+		p, r.truncated = p[:room], true
+	}
+	r.partial = append(r.partial, p...)
+}
+
+// partialLine returns the unterminated final line, marked if truncated.
+func (r *lineRing) partialLine() string {
+	if r.truncated {
+		return string(r.partial) + "..."
+	}
+	return string(r.partial)
+}
+
+func (r *lineRing) add(line string) {
+	if len(r.lines) < r.n {
+		r.lines = append(r.lines, line)
+		return
+	}
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % r.n
+}
+
+// Lines returns the retained lines, oldest first, including any
+// unterminated final line.
+func (r *lineRing) Lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lines := append(append([]string(nil), r.lines[r.next:]...), r.lines[:r.next]...)
+	if len(r.partial) > 0 {
+		lines = append(lines, r.partialLine())
+		if len(lines) > r.n {
+			lines = lines[1:]
+		}
+	}
+	return lines
+}
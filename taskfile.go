@@ -0,0 +1,414 @@
+package exec
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadTasks reads the task file at path, see ParseTasks.
+func LoadTasks(path string) ([]Task, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tasks, err := ParseTasks(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return tasks, nil
+}
+
+// ParseTasks reads task definitions written in a simple subset of YAML,
+// returning them in the order they appear, ready to be run by a Graph.
+//
+//	tasks:
+//	  generate:
+//	    cmd: go generate ./...
+//	    sources: ["*.proto"]
+//	    outputs: [api.pb.go]
+//	  build:
+//	    cmd: go build -o bin/app .
+//	    deps: [generate]
+//	    dir: cmd/app
+//	    env:
+//	      CGO_ENABLED: "0"
+//
+// Each cmd is run by the shell. Relative sources and outputs are taken
+// to be relative to the task's dir, and a task whose outputs all exist
+// and are no older than its sources is up to date and is not run. Tasks
+// without a cmd are never run, but may be used to group their deps.
+//
+// Only block mappings, block and flow sequences of scalars, and plain or
+// quoted scalars are supported.
+func ParseTasks(r io.Reader) ([]Task, error) {
+	lines, err := yamlLines(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &yamlParser{lines: lines}
+	doc, err := p.mapping(0)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []Task
+	for _, e := range doc {
+		if e.key != "tasks" {
+			return nil, fmt.Errorf("exec: line %d: unknown key %q", e.line, e.key)
+		}
+		m, ok := e.value.(yamlMapping)
+		if !ok {
+			return nil, fmt.Errorf("exec: line %d: tasks must be a mapping", e.line)
+		}
+		for _, te := range m {
+			t, err := parseTask(te)
+			if err != nil {
+				return nil, err
+			}
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
+
+func parseTask(te yamlEntry) (Task, error) {
+	t := Task{Name: te.key}
+	m, ok := te.value.(yamlMapping)
+	if !ok && te.value != "" {
+		return t, fmt.Errorf("exec: line %d: task %q must be a mapping", te.line, te.key)
+	}
+	var cmd, dir string
+	var sources []string
+	var opts []func(*Cmd) error
+	for _, e := range m {
+		var err error
+		switch e.key {
+		case "cmd":
+			cmd, err = e.str()
+		case "dir":
+			dir, err = e.str()
+		case "deps":
+			t.Deps, err = e.list()
+		case "sources":
+			sources, err = e.list()
+		case "outputs":
+			t.Outputs, err = e.list()
+		case "env":
+			env, ok := e.value.(yamlMapping)
+			if !ok {
+				return t, fmt.Errorf("exec: line %d: env must be a mapping", e.line)
+			}
+			for _, v := range env {
+				val, err := v.str()
+				if err != nil {
+					return t, err
+				}
+				opts = append(opts, Setenv(v.key, val))
+			}
+		default:
+			return t, fmt.Errorf("exec: line %d: task %q has unknown key %q", e.line, t.Name, e.key)
+		}
+		if err != nil {
+			return t, err
+		}
+	}
+	for _, paths := range [][]string{sources, t.Outputs} {
+		for i, p := range paths {
+			if _, err := filepath.Match(p, ""); err != nil {
+				return t, fmt.Errorf("exec: task %q: %q: %w", t.Name, p, err)
+			}
+			if !filepath.IsAbs(p) {
+				paths[i] = filepath.Join(dir, p)
+			}
+		}
+	}
+	t.Inputs = sources
+	if cmd == "" {
+		t.UpToDate = func() bool { return true }
+		return t, nil
+	}
+	if dir != "" {
+		opts = append(opts, Dir(dir))
+	}
+	t.Spec = shellSpec(cmd)
+	t.Spec.Opts = opts
+	if len(t.Outputs) > 0 {
+		outputs := t.Outputs
+		t.UpToDate = func() bool { return upToDate(sources, outputs) }
+	}
+	return t, nil
+}
+
+// shellSpec returns a Spec which runs cmd with the platform's shell.
+func shellSpec(cmd string) Spec {
+	if runtime.GOOS == "windows" {
+		return Spec{Name: "cmd", Args: []string{"/C", cmd}}
+	}
+	return Spec{Name: "/bin/sh", Args: []string{"-c", cmd}}
+}
+
+// upToDate reports whether the files matching outputs all exist and are
+// no older than the newest file matching sources.
+func upToDate(sources, outputs []string) bool {
+	var oldest time.Time
+	for _, pattern := range outputs {
+		files, _ := filepath.Glob(pattern)
+		if len(files) == 0 {
+			return false
+		}
+		for _, f := range files {
+			fi, err := os.Stat(f)
+			if err != nil {
+				return false
+			}
+			if oldest.IsZero() || fi.ModTime().Before(oldest) {
+				oldest = fi.ModTime()
+			}
+		}
+	}
+	for _, pattern := range sources {
+		files, _ := filepath.Glob(pattern)
+		for _, f := range files {
+			fi, err := os.Stat(f)
+			if err != nil || fi.ModTime().After(oldest) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// yamlMapping is a block mapping, in the order the keys appear.
+type yamlMapping []yamlEntry
+
+// yamlEntry is a key in a mapping. value is a string, []string, or
+// yamlMapping.
+type yamlEntry struct {
+	line  int
+	key   string
+	value interface{}
+}
+
+func (e yamlEntry) str() (string, error) {
+	s, ok := e.value.(string)
+	if !ok {
+		return "", fmt.Errorf("exec: line %d: %s must be a string", e.line, e.key)
+	}
+	return s, nil
+}
+
+func (e yamlEntry) list() ([]string, error) {
+	l, ok := e.value.([]string)
+	if !ok {
+		return nil, fmt.Errorf("exec: line %d: %s must be a list", e.line, e.key)
+	}
+	return l, nil
+}
+
+type yamlLine struct {
+	n      int // line number
+	indent int
+	text   string // without indentation or comment
+}
+
+// yamlLines returns the non blank lines of r, with comments removed.
+func yamlLines(r io.Reader) ([]yamlLine, error) {
+	var lines []yamlLine
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		text := stripComment(sc.Text())
+		trimmed := strings.TrimLeft(text, " ")
+		if strings.TrimSpace(trimmed) == "" {
+			continue
+		}
+		if trimmed[0] == '\t' {
+			return nil, fmt.Errorf("exec: line %d: tabs may not be used for indentation", n)
+		}
+		lines = append(lines, yamlLine{
+			n:      n,
+			indent: len(text) - len(trimmed),
+			text:   strings.TrimRight(trimmed, " \t"),
+		})
+	}
+	return lines, sc.Err()
+}
+
+// stripComment removes a trailing comment, which starts with a # at the
+// beginning of the line or after whitespace, outside of quoted scalars.
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t[,", s[i-1]) >= 0):
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func (p *yamlParser) mapping(indent int) (yamlMapping, error) {
+	var m yamlMapping
+	seen := make(map[string]bool)
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent < indent {
+			break
+		}
+		if l.indent > indent {
+			return nil, fmt.Errorf("exec: line %d: unexpected indentation", l.n)
+		}
+		key, val, ok := splitKey(l.text)
+		if !ok {
+			return nil, fmt.Errorf("exec: line %d: want key: value", l.n)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("exec: line %d: duplicate key %q", l.n, key)
+		}
+		seen[key] = true
+		p.pos++
+		e := yamlEntry{line: l.n, key: key}
+		var err error
+		switch next, more := p.peek(); {
+		case val != "":
+			e.value, err = yamlValue(l.n, val)
+		case more && isSeqItem(next.text) && next.indent >= indent:
+			e.value, err = p.sequence(next.indent)
+		case more && next.indent > indent:
+			e.value, err = p.mapping(next.indent)
+		default:
+			e.value = ""
+		}
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, e)
+	}
+	return m, nil
+}
+
+func (p *yamlParser) sequence(indent int) ([]string, error) {
+	var s []string
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent != indent || !isSeqItem(l.text) {
+			break
+		}
+		p.pos++
+		v, err := yamlScalar(l.n, strings.TrimSpace(l.text[1:]))
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, v)
+	}
+	return s, nil
+}
+
+func (p *yamlParser) peek() (yamlLine, bool) {
+	if p.pos < len(p.lines) {
+		return p.lines[p.pos], true
+	}
+	return yamlLine{}, false
+}
+
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitKey splits a "key: value" line.
+func splitKey(text string) (key, val string, ok bool) {
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			key = strings.TrimSpace(text[:i])
+			return key, strings.TrimSpace(text[i+1:]), key != "" && !isSeqItem(text)
+		}
+	}
+	return "", "", false
+}
+
+// yamlValue parses an inline value, which is either a flow sequence
+// or a scalar.
+func yamlValue(n int, s string) (interface{}, error) {
+	if !strings.HasPrefix(s, "[") {
+		return yamlScalar(n, s)
+	}
+	if !strings.HasSuffix(s, "]") {
+		return nil, fmt.Errorf("exec: line %d: unterminated sequence", n)
+	}
+	l := []string{}
+	for _, item := range splitFlow(s[1 : len(s)-1]) {
+		v, err := yamlScalar(n, strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, v)
+	}
+	return l, nil
+}
+
+// splitFlow splits the items of a flow sequence at commas outside quotes.
+func splitFlow(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, s[start:])
+}
+
+func yamlScalar(n int, s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	switch s[0] {
+	case '"':
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return "", fmt.Errorf("exec: line %d: invalid quoted string %s", n, s)
+		}
+		return v, nil
+	case '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return "", fmt.Errorf("exec: line %d: invalid quoted string %s", n, s)
+		}
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	case '[', '{', '|', '>', '&', '*', '!':
+		return "", fmt.Errorf("exec: line %d: unsupported value %s", n, s)
+	}
+	return s, nil
+}
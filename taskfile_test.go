@@ -0,0 +1,89 @@
+package exec_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/exec"
+)
+
+const testTasks = `
+# build the app
+tasks:
+  generate:
+    cmd: touch gen.go
+    sources: ["*.proto"]
+    outputs: [gen.go]
+  build:
+    cmd: 'echo "building" > app'
+    deps:
+    - generate
+    env:
+      CGO_ENABLED: "0"
+  all:
+    deps: [build]
+`
+
+func TestParseTasks(t *testing.T) {
+	tasks, err := exec.ParseTasks(strings.NewReader(testTasks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, task := range tasks {
+		names = append(names, task.Name)
+	}
+	if got, want := names, []string{"generate", "build", "all"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("names: want %v, got %v", want, got)
+	}
+	if got, want := tasks[1].Spec.Args, []string{"-c", `echo "building" > app`}; runtime.GOOS != "windows" && !reflect.DeepEqual(got, want) {
+		t.Errorf("build: want args %q, got %q", want, got)
+	}
+	if got, want := tasks[1].Deps, []string{"generate"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("build: want deps %v, got %v", want, got)
+	}
+	if !tasks[2].UpToDate() {
+		t.Error("all: want task without cmd to be up to date")
+	}
+
+	_, err = exec.ParseTasks(strings.NewReader("tasks:\n  a:\n    command: true\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("want error on line 3, got %v", err)
+	}
+}
+
+func TestTaskUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	tasks, err := exec.ParseTasks(strings.NewReader("tasks:\n  gen:\n    cmd: true\n    dir: " + dir + "\n    sources: [a.in]\n    outputs: [a.out]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen := tasks[0]
+	write := func(name string, mtime time.Time) {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	write("a.in", now)
+	if gen.UpToDate() {
+		t.Error("want missing output to be out of date")
+	}
+	write("a.out", now.Add(-time.Hour))
+	if gen.UpToDate() {
+		t.Error("want output older than source to be out of date")
+	}
+	write("a.out", now.Add(time.Hour))
+	if !gen.UpToDate() {
+		t.Error("want output newer than source to be up to date")
+	}
+}
@@ -0,0 +1,49 @@
+package exec
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// TempDir runs the command in a new temporary directory, whose name is
+// made from pattern as by ioutil.TempDir, and which is removed with its
+// contents once the command has exited. The child's TMPDIR is also set
+// to the directory, so the temporary files of the tools it runs are
+// removed with it. Its path is returned by the command's TempDir
+// method, and it is kept if the command fails and KeepOnFailure is set.
+//
+//	cmd.Run(exec.TempDir("build-"), exec.KeepOnFailure())
+func TempDir(pattern string) func(*Cmd) error {
+	return Describe("TempDir", []Param{{"pattern", pattern}}, func(c *Cmd) error {
+		c.starting = append(c.starting, func(c *Cmd) error {
+			dir, err := ioutil.TempDir("", pattern)
+			if err != nil {
+				return err
+			}
+			c.tempDir, c.Dir = dir, dir
+			c.finished = append(c.finished, func(c *Cmd) error {
+				if c.keepOnFailure && c.outcome() != nil {
+					return nil
+				}
+				return os.RemoveAll(dir)
+			})
+			return applyOptions(c, Setenv("TMPDIR", dir))
+		})
+		return nil
+	})
+}
+
+// KeepOnFailure keeps the directory created by TempDir if the command
+// fails to start or exits unsuccessfully, so that it may be inspected.
+func KeepOnFailure() func(*Cmd) error {
+	return Describe("KeepOnFailure", nil, func(c *Cmd) error {
+		c.keepOnFailure = true
+		return nil
+	})
+}
+
+// TempDir returns the directory created for the command by TempDir, or
+// "" if there is none.
+func (c *Cmd) TempDir() string {
+	return c.tempDir
+}
@@ -0,0 +1,77 @@
+package exec
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrIdleTimeout is returned by Wait when the command was killed
+// by IdleTimeout.
+var ErrIdleTimeout = errors.New("exec: idle timeout")
+
+// IdleTimeout kills the command if it writes nothing to stdout or stderr
+// for d. The timer is reset by each write, so unlike an overall deadline
+// a command may run for as long as it continues to make progress.
+func IdleTimeout(d time.Duration) func(*Cmd) error {
+	return Describe("IdleTimeout", []Param{{"d", d}}, func(c *Cmd) error {
+		if d <= 0 {
+			return errors.New("exec: IdleTimeout must be positive")
+		}
+		it := &idleTimer{d: d}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			wrapOutput(c, func(w io.Writer) io.Writer {
+				return &idleWriter{w: w, t: it}
+			})
+			return nil
+		})
+		c.started = append(c.started, func(c *Cmd) error {
+			it.start(c.clk(), func() { c.kill(ErrIdleTimeout) })
+			return nil
+		})
+		c.finished = append(c.finished, func(*Cmd) error {
+			it.stop()
+			return nil
+		})
+		return nil
+	})
+}
+
+type idleTimer struct {
+	d  time.Duration
+	mu sync.Mutex
+	t  Timer
+}
+
+func (i *idleTimer) start(clk Clock, fn func()) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.t = clk.AfterFunc(i.d, fn)
+}
+
+func (i *idleTimer) reset() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.t != nil {
+		i.t.Reset(i.d)
+	}
+}
+
+func (i *idleTimer) stop() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.t != nil {
+		i.t.Stop()
+	}
+}
+
+type idleWriter struct {
+	w io.Writer
+	t *idleTimer
+}
+
+func (w *idleWriter) Write(p []byte) (int, error) {
+	w.t.reset()
+	return w.w.Write(p)
+}
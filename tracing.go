@@ -0,0 +1,86 @@
+package exec
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+)
+
+// Tracer starts a span for each command run with WithTracing. This
+// package does not depend on a tracing system; Tracer and Span are
+// implemented by a small adapter, for example one wrapping an
+// OpenTelemetry trace.Tracer and the context of the caller's span.
+type Tracer interface {
+	Start(name string) Span
+}
+
+// Span is the operation of running a command, traced by a Tracer.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	// TraceParent returns the W3C traceparent header for the span, which
+	// is passed to the child so it may continue the trace, or "".
+	TraceParent() string
+	// End ends the span, recording err if the command failed.
+	End(err error)
+}
+
+// TraceParentEnv is the environment variable through which WithTracing
+// passes the trace context to the child, as many tracing libraries
+// expect.
+const TraceParentEnv = "TRACEPARENT"
+
+// WithTracing traces the command as a span started by tracer, named
+// "exec " and the program's base name, from just before the command
+// starts until it has exited. The span records the program, its
+// arguments as returned by RedactedArgs, its process ID and its exit
+// code, with the OpenTelemetry semantic convention attribute names, and
+// its trace context is passed to the child in TRACEPARENT.
+func WithTracing(tracer Tracer) func(*Cmd) error {
+	return Describe("WithTracing", []Param{{"tracer", tracer}}, func(c *Cmd) error {
+		var span Span
+		c.starting = append(c.starting, func(c *Cmd) error {
+			span = tracer.Start("exec " + filepath.Base(c.Path))
+			span.SetAttribute("process.executable.path", c.Redact(c.Path))
+			span.SetAttribute("process.command_args", c.RedactedArgs())
+			if tp := span.TraceParent(); tp != "" {
+				return applyOptions(c, Setenv(TraceParentEnv, tp))
+			}
+			return nil
+		})
+		c.started = append(c.started, func(c *Cmd) error {
+			span.SetAttribute("process.pid", c.Process.Pid)
+			return nil
+		})
+		c.finished = append(c.finished, func(c *Cmd) error {
+			if span == nil {
+				return nil // an earlier option failed
+			}
+			if c.ProcessState != nil {
+				span.SetAttribute("process.exit.code", c.ProcessState.ExitCode())
+			}
+			span.End(c.outcome())
+			return nil
+		})
+		return nil
+	})
+}
+
+var errNotStarted = errors.New("exec: command did not start")
+
+// outcome returns, from a finished hook, why the command failed: the
+// reason it was killed, the *exec.ExitError for an unsuccessful exit
+// status, or errNotStarted. It returns nil if the command succeeded.
+func (c *Cmd) outcome() error {
+	c.mu.Lock()
+	killed := c.killed
+	c.mu.Unlock()
+	switch {
+	case killed != nil:
+		return killed
+	case c.Process == nil:
+		return errNotStarted
+	case c.ProcessState != nil && !c.ProcessState.Success():
+		return &exec.ExitError{ProcessState: c.ProcessState}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transcript records the commands run with its Record option, with their
+// timing and interleaved output, so they may be exported as a report.
+// The zero value is ready to use.
+type Transcript struct {
+	mu      sync.Mutex
+	entries []*TranscriptEntry
+}
+
+// TranscriptEntry is a command recorded by a Transcript.
+type TranscriptEntry struct {
+	RunID    string   // see Correlate
+	Args     []string // the command line, including the program
+	Dir      string
+	Start    time.Time
+	Duration time.Duration
+	ExitCode int // -1 if the command did not exit normally
+	Output   []TranscriptLine
+}
+
+// TranscriptLine is a line of output recorded by a Transcript.
+type TranscriptLine struct {
+	Time   time.Time
+	Stream string // "stdout", "stderr", or "output" if they share a writer
+	Text   string // without the trailing newline
+}
+
+// Record records the command and its output in t. The output is still
+// passed to any writers set by Stdout or Stderr.
+func (t *Transcript) Record() func(*Cmd) error {
+	return Describe("Transcript.Record", nil, func(c *Cmd) error {
+		e := &TranscriptEntry{ExitCode: -1}
+		var recs []*transcriptWriter
+		c.starting = append(c.starting, func(c *Cmd) error {
+			e.RunID = c.runID
+			e.Args = c.redactAll(c.Args)
+			e.Dir = c.Dir
+			streams := []string{"stdout", "stderr"}
+			if interfaceEqual(c.Stdout, c.Stderr) {
+				streams = []string{"output"}
+			}
+			wrapOutput(c, func(w io.Writer) io.Writer {
+				tw := &transcriptWriter{t: t, e: e, clk: c.clk(), redact: c.Redact, stream: streams[len(recs)]}
+				recs = append(recs, tw)
+				return io.MultiWriter(w, tw)
+			})
+			e.Start = c.clk().Now()
+			t.mu.Lock()
+			t.entries = append(t.entries, e)
+			t.mu.Unlock()
+			return nil
+		})
+		c.finished = append(c.finished, func(c *Cmd) error {
+			for _, tw := range recs {
+				tw.flush()
+			}
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			e.Duration = c.clk().Now().Sub(e.Start)
+			if c.ProcessState != nil {
+				e.ExitCode = c.ProcessState.ExitCode()
+			}
+			return nil
+		})
+		return nil
+	})
+}
+
+// Entries returns a copy of the commands recorded so far, in the order
+// they were started.
+func (t *Transcript) Entries() []TranscriptEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entries := make([]TranscriptEntry, len(t.entries))
+	for i, e := range t.entries {
+		entries[i] = *e
+		entries[i].Output = append([]TranscriptLine(nil), e.Output...)
+	}
+	return entries
+}
+
+// transcriptWriter records the lines written to it in e.
+type transcriptWriter struct {
+	t      *Transcript // guards e
+	e      *TranscriptEntry
+	clk    Clock
+	redact func(string) string
+	stream string
+
+	partial []byte // guarded by t.mu
+}
+
+func (tw *transcriptWriter) Write(p []byte) (int, error) {
+	tw.t.mu.Lock()
+	defer tw.t.mu.Unlock()
+	now := tw.clk.Now()
+	n := len(p)
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			break
+		}
+		tw.add(now, string(append(tw.partial, p[:i]...)))
+		tw.partial = tw.partial[:0]
+		p = p[i+1:]
+	}
+	tw.partial = append(tw.partial, p...)
+	return n, nil
+}
+
+func (tw *transcriptWriter) add(now time.Time, text string) {
+	tw.e.Output = append(tw.e.Output, TranscriptLine{Time: now, Stream: tw.stream, Text: tw.redact(text)})
+}
+
+func (tw *transcriptWriter) flush() {
+	tw.t.mu.Lock()
+	defer tw.t.mu.Unlock()
+	if len(tw.partial) > 0 {
+		tw.add(tw.clk.Now(), string(tw.partial))
+		tw.partial = nil
+	}
+}
+
+// WriteMarkdown writes the transcript to w as a Markdown document, with a
+// section and code block for each command.
+func (t *Transcript) WriteMarkdown(w io.Writer) error {
+	var b bytes.Buffer
+	for i, e := range t.Entries() {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		cmdline := strings.Join(e.Args, " ")
+		fence := codeFence(cmdline, "`")
+		fmt.Fprintf(&b, "### %s %s %s\n\n", fence, cmdline, fence)
+		fmt.Fprintf(&b, "- started: %s\n", e.Start.Format(time.RFC3339))
+		fmt.Fprintf(&b, "- duration: %s\n", e.Duration.Round(time.Millisecond))
+		fmt.Fprintf(&b, "- exit code: %d\n", e.ExitCode)
+		if e.Dir != "" {
+			fmt.Fprintf(&b, "- directory: %s\n", e.Dir)
+		}
+		if e.RunID != "" {
+			fmt.Fprintf(&b, "- run ID: %s\n", e.RunID)
+		}
+		if len(e.Output) == 0 {
+			continue
+		}
+		var out strings.Builder
+		for _, l := range e.Output {
+			out.WriteString(l.Text)
+			out.WriteString("\n")
+		}
+		fence = codeFence(out.String(), "```")
+		fmt.Fprintf(&b, "\n%stext\n%s%s\n", fence, out.String(), fence)
+	}
+	_, err := b.WriteTo(w)
+	return err
+}
+
+// codeFence returns the shortest run of the first character of fence,
+// at least as long as fence, which does not appear in s.
+func codeFence(s, fence string) string {
+	for strings.Contains(s, fence) {
+		fence += fence[:1]
+	}
+	return fence
+}
+
+// WriteHTML writes the transcript to w as a self contained HTML document.
+// Output written to stderr is highlighted.
+func (t *Transcript) WriteHTML(w io.Writer) error {
+	return transcriptHTML.Execute(w, t.Entries())
+}
+
+var transcriptHTML = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"join": strings.Join,
+	"rfc3339": func(t time.Time) string {
+		return t.Format(time.RFC3339)
+	},
+	"round": func(d time.Duration) time.Duration {
+		return d.Round(time.Millisecond)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Transcript</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+.cmd { border: 1px solid #ccc; border-radius: 4px; margin-bottom: 1.5em; }
+.cmd h2 { font-family: monospace; font-size: 1em; margin: 0; padding: .5em; background: #f4f4f4; }
+.cmd .meta { color: #666; font-size: .9em; padding: .5em; }
+.cmd .fail { color: #b00; }
+.cmd pre { margin: 0; padding: .5em; background: #fafafa; overflow-x: auto; }
+.cmd .stderr { color: #b00; }
+</style>
+</head>
+<body>
+{{range .}}<div class="cmd">
+<h2>{{join .Args " "}}</h2>
+<div class="meta">started {{rfc3339 .Start}}, took {{round .Duration}}, <span{{if ne .ExitCode 0}} class="fail"{{end}}>exit code {{.ExitCode}}</span>{{with .Dir}}, in {{.}}{{end}}{{with .RunID}}, run ID {{.}}{{end}}</div>
+{{if .Output}}<pre>{{range .Output}}<span class="{{.Stream}}" title="{{rfc3339 .Time}}">{{.Text}}</span>
+{{end}}</pre>
+{{end}}</div>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,113 @@
+package exec_test
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/exec"
+	"github.com/pkg/exec/exectest"
+)
+
+func TestTranscript(t *testing.T) {
+	var tr exec.Transcript
+	var stdout, stderr bytes.Buffer
+	err := helperCommand(t, "pipetest").Run(
+		exec.Stdin(strings.NewReader("O:out\nE:<err>\n")),
+		exec.Stdout(&stdout),
+		exec.Stderr(&stderr),
+		tr.Record(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := helperCommand(t, "exit", "3").Run(tr.Record()); err == nil {
+		t.Fatal("exit: want error")
+	}
+	if stdout.String() != "O:out\n" || stderr.String() != "E:<err>\n" {
+		t.Errorf("want output passed through, got %q, %q", stdout.String(), stderr.String())
+	}
+
+	entries := tr.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("want 2 entries, got %d", len(entries))
+	}
+	// stdout and stderr are read concurrently, so may be recorded in
+	// either order.
+	got := entries[0].Output
+	if len(got) == 2 && got[0].Stream == "stderr" {
+		got[0], got[1] = got[1], got[0]
+	}
+	if len(got) != 2 || got[0].Stream != "stdout" || got[1].Stream != "stderr" || got[1].Text != "E:<err>" {
+		t.Errorf("pipetest: want a stdout and a stderr line, got %+v", got)
+	}
+	if got := entries[1].ExitCode; got != 3 {
+		t.Errorf("exit: want exit code 3, got %d", got)
+	}
+
+	var md bytes.Buffer
+	if err := tr.WriteMarkdown(&md); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(md.String(), "\nO:out\n") || !strings.Contains(md.String(), "\nE:<err>\n") || !strings.Contains(md.String(), "- exit code: 3\n") {
+		t.Errorf("unexpected markdown:\n%s", md.String())
+	}
+
+	var html bytes.Buffer
+	if err := tr.WriteHTML(&html); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html.String(), `<span class="stderr"`) || !strings.Contains(html.String(), "E:&lt;err&gt;") {
+		t.Errorf("unexpected html:\n%s", html.String())
+	}
+}
+
+func TestReplayer(t *testing.T) {
+	start := time.Date(2021, 3, 15, 10, 30, 0, 0, time.UTC)
+	entries := []exec.TranscriptEntry{{
+		Args:  []string{"make", "test"},
+		Start: start,
+		Output: []exec.TranscriptLine{
+			{Time: start.Add(time.Second), Stream: "stdout", Text: "building"},
+			{Time: start.Add(5 * time.Second), Stream: "stderr", Text: "FAIL"},
+		},
+	}}
+	clk := exectest.NewFakeClock(start)
+	var mu sync.Mutex
+	var out bytes.Buffer
+	w := writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return out.Write(p)
+	})
+	done := make(chan error, 1)
+	r := &exec.Replayer{Speed: 2, Clock: clk}
+	go func() { done <- r.Replay(w, entries) }()
+	output := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return out.String()
+	}
+	advance := func(d time.Duration, want string) {
+		for clk.Pending() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		clk.Advance(d)
+		for deadline := time.Now().Add(5 * time.Second); output() != want; time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("want %q, got %q", want, output())
+			}
+		}
+	}
+	advance(500*time.Millisecond, "$ make test\nbuilding\n")
+	advance(2*time.Second, "$ make test\nbuilding\nFAIL\n")
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (fn writerFunc) Write(p []byte) (int, error) { return fn(p) }
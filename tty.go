@@ -0,0 +1,53 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// InheritTTY connects those of the child's stdin, stdout and stderr
+// which have not already been set to the controlling terminal of the
+// parent, even if the parent's own standard streams are redirected.
+// This lets commands which prompt the user, such as ssh or gpg, do so
+// from within a pipeline. It is an error if the parent has no
+// controlling terminal. The child must remain in the terminal's session,
+// so InheritTTY cannot be combined with NewSession.
+func InheritTTY() func(*Cmd) error {
+	return Describe("InheritTTY", nil, func(c *Cmd) error {
+		in, out, err := openTTY()
+		if err != nil {
+			return fmt.Errorf("exec: InheritTTY: %v", err)
+		}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			for _, o := range c.applied {
+				if o.Name == "NewSession" {
+					return errors.New("exec: InheritTTY cannot be used with NewSession")
+				}
+			}
+			return nil
+		})
+		c.finished = append(c.finished, func(*Cmd) error {
+			in.Close()
+			if out != in {
+				out.Close()
+			}
+			return nil
+		})
+		if c.Stdin == nil {
+			c.Stdin = in
+		}
+		if c.Stdout == nil {
+			c.Stdout = out
+		}
+		if c.Stderr == nil {
+			c.Stderr = out
+		}
+		return nil
+	})
+}
+
+// ttyFile opens name for reading and writing.
+func ttyFile(name string) (*os.File, error) {
+	return os.OpenFile(name, os.O_RDWR, 0)
+}
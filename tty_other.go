@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package exec
+
+import (
+	"os"
+	"runtime"
+)
+
+// openTTY opens the controlling terminal of the process.
+func openTTY() (in, out *os.File, err error) {
+	name := "/dev/tty"
+	if runtime.GOOS == "plan9" {
+		name = "/dev/cons"
+	}
+	f, err := ttyFile(name)
+	return f, f, err
+}
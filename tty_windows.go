@@ -0,0 +1,15 @@
+package exec
+
+import "os"
+
+// openTTY opens the console attached to the process.
+func openTTY() (in, out *os.File, err error) {
+	if in, err = ttyFile("CONIN$"); err != nil {
+		return nil, nil, err
+	}
+	if out, err = ttyFile("CONOUT$"); err != nil {
+		in.Close()
+		return nil, nil, err
+	}
+	return in, out, nil
+}
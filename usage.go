@@ -0,0 +1,32 @@
+package exec
+
+import "time"
+
+// Usage describes the resources used by a command which has exited.
+// Fields which the platform does not report are zero.
+type Usage struct {
+	WallTime    time.Duration // from Start until the command exited
+	UserTime    time.Duration // CPU time in user mode
+	SystemTime  time.Duration // CPU time in kernel mode
+	MaxRSS      int64         // peak resident set size, in bytes
+	MinorFaults int64         // page faults serviced without I/O
+	MajorFaults int64         // page faults which required I/O
+	InBlocks    int64         // block input operations
+	OutBlocks   int64         // block output operations
+}
+
+// Usage returns the resources used by the command, or nil if it has not
+// exited. The figures include those of any children the command waited
+// for.
+func (c *Cmd) Usage() *Usage {
+	if c.ProcessState == nil {
+		return nil
+	}
+	u := &Usage{
+		WallTime:   c.exitTime.Sub(c.startTime),
+		UserTime:   c.ProcessState.UserTime(),
+		SystemTime: c.ProcessState.SystemTime(),
+	}
+	sysUsage(u, c.ProcessState.SysUsage())
+	return u
+}
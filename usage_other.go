@@ -0,0 +1,8 @@
+//go:build windows || plan9 || js || wasip1
+// +build windows plan9 js wasip1
+
+package exec
+
+// sysUsage does nothing, as no more than CPU times are reported on
+// Windows, Plan 9, js and wasip1.
+func sysUsage(u *Usage, sys interface{}) {}
@@ -0,0 +1,24 @@
+//go:build !windows && !plan9 && !js && !wasip1
+// +build !windows,!plan9,!js,!wasip1
+
+package exec
+
+import (
+	"runtime"
+	"syscall"
+)
+
+func sysUsage(u *Usage, sys interface{}) {
+	ru, ok := sys.(*syscall.Rusage)
+	if !ok {
+		return
+	}
+	u.MaxRSS = int64(ru.Maxrss)
+	if runtime.GOOS != "darwin" && runtime.GOOS != "ios" {
+		u.MaxRSS *= 1024 // reported in kilobytes
+	}
+	u.MinorFaults = int64(ru.Minflt)
+	u.MajorFaults = int64(ru.Majflt)
+	u.InBlocks = int64(ru.Inblock)
+	u.OutBlocks = int64(ru.Oublock)
+}
@@ -0,0 +1,54 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrChecksumMismatch is wrapped by the errors returned by VerifySHA256
+// and Verify when a program is not the one expected.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// VerifySHA256 checks that the SHA-256 of the file at path, hex encoded,
+// is expectedHex.
+func VerifySHA256(path, expectedHex string) error {
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(sum, expectedHex) {
+		return fmt.Errorf("exec: %s: %w: got SHA-256 %s, want %s", path, ErrChecksumMismatch, sum, expectedHex)
+	}
+	return nil
+}
+
+// Verify refuses to start the command unless the program, once resolved
+// to an absolute path, has the SHA-256 given for it in checksums, hex
+// encoded. Programs are looked up as by RegisterDefaults, by absolute
+// path and then by base name with any .exe extension removed, and a
+// program not in checksums is refused.
+//
+// The program is hashed before it is started, so Verify guards against
+// an unexpected binary on PATH or a tampered install, not against one
+// replaced by an attacker in the moment between the two.
+//
+//	exec.Verify(map[string]string{
+//		"protoc": "5a0b...",
+//	})
+func Verify(checksums map[string]string) func(*Cmd) error {
+	return Describe("Verify", []Param{{"checksums", checksums}}, func(c *Cmd) error {
+		c.resolved = append(c.resolved, func(c *Cmd) error {
+			want, ok := checksums[c.Path]
+			if !ok {
+				want, ok = checksums[strings.TrimSuffix(filepath.Base(c.Path), ".exe")]
+			}
+			if !ok {
+				return fmt.Errorf("exec: %s: %w: no checksum given", c.Path, ErrChecksumMismatch)
+			}
+			return VerifySHA256(c.Path, want)
+		})
+		return nil
+	})
+}
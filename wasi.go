@@ -0,0 +1,92 @@
+package exec
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// WASIRuntime is a WebAssembly runtime able to run WASI modules, see WASI.
+type WASIRuntime interface {
+	// Command returns the command line, starting with the runtime
+	// program, which runs module with args. The guest should see env as
+	// its environment, and the working directory of the runtime as its
+	// own. The runtime itself runs with env, so it may pass the guest
+	// the names of the variables rather than their values.
+	Command(module string, args, env []string) []string
+}
+
+// WASI runs the command's program, which must be a WASI module, with rt,
+// so a command may be moved between running natively and in a wasm
+// sandbox without changing the caller. The module's arguments,
+// environment and working directory are those of the command, and its
+// stdin, stdout and stderr are connected as usual.
+//
+//	exec.Command("plugin.wasm", "-v").Run(exec.WASI(exec.Wasmtime))
+func WASI(rt WASIRuntime) func(*Cmd) error {
+	return Describe("WASI", []Param{{"rt", rt}}, func(c *Cmd) error {
+		if rt == nil {
+			return errors.New("exec: WASI runtime must not be nil")
+		}
+		c.starting = append(c.starting, func(c *Cmd) error {
+			module, err := filepath.Abs(filepath.Join(c.Dir, c.Path))
+			if filepath.IsAbs(c.Path) {
+				module, err = c.Path, nil
+			}
+			if err != nil {
+				return err
+			}
+			args := rt.Command(module, c.Args[1:], c.Env)
+			if len(args) == 0 {
+				return errors.New("exec: WASI runtime returned an empty command")
+			}
+			path, err := LookPath(args[0])
+			if err != nil {
+				return err
+			}
+			// the module itself need not be executable or on PATH.
+			c.Path, c.Args, c.Err = path, args, nil
+			return nil
+		})
+		return nil
+	})
+}
+
+// Wasmtime runs WASI modules with the wasmtime command line tool.
+var Wasmtime WASIRuntime = wasmtime{}
+
+// Wasmer runs WASI modules with the wasmer command line tool.
+var Wasmer WASIRuntime = wasmer{}
+
+type wasmtime struct{}
+
+func (wasmtime) Command(module string, args, env []string) []string {
+	cmd := []string{"wasmtime", "run", "--dir=."}
+	for _, key := range envKeys(env) {
+		cmd = append(cmd, "--env", key)
+	}
+	return append(append(cmd, "--", module), args...)
+}
+
+type wasmer struct{}
+
+func (wasmer) Command(module string, args, env []string) []string {
+	cmd := []string{"wasmer", "run", "--dir=."}
+	for _, key := range envKeys(env) {
+		cmd = append(cmd, "--env", key)
+	}
+	return append(append(cmd, module, "--"), args...)
+}
+
+// envKeys returns the names of the variables in env. The runtimes are
+// passed only the names, and take the values from their own
+// environment, so that secrets do not appear on their command line.
+func envKeys(env []string) []string {
+	keys := make([]string, 0, len(env))
+	for _, kv := range env {
+		if i := strings.IndexByte(kv, '='); i > 0 {
+			keys = append(keys, kv[:i])
+		}
+	}
+	return keys
+}
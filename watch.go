@@ -0,0 +1,211 @@
+package exec
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WatchOptions controls how Watch watches files and restarts its
+// command.
+type WatchOptions struct {
+	// Interval is how often the files are checked for changes. The
+	// default is 250ms.
+	Interval time.Duration
+
+	// Debounce is how long the files must be unchanged before the
+	// command is restarted, so that a burst of changes, such as a
+	// checkout or a build, restarts it once. The default is 100ms.
+	Debounce time.Duration
+
+	// StopSignal and StopGrace control how the running command is
+	// stopped before it is restarted, see Cmd.Stop. The defaults are
+	// os.Interrupt and 10s.
+	StopSignal os.Signal
+	StopGrace  time.Duration
+
+	// OnExit, if non nil, is called with the error from each run of the
+	// command once it exits or is stopped, or from starting it.
+	OnExit func(error)
+
+	// Clock, if non nil, is used to check for changes and by each run of
+	// the command, see UseClock.
+	Clock Clock
+}
+
+// Watcher runs a command, restarting it whenever the files it watches
+// change. It is returned by Watch.
+type Watcher struct {
+	paths []string
+	spec  Spec
+	opts  WatchOptions
+
+	mu    sync.Mutex
+	stopc chan struct{}
+	done  chan struct{}
+}
+
+// Watch starts the command described by spec, and restarts it whenever
+// a file in paths changes, stopping the previous run first if it is
+// still running. Directories are watched recursively, except for those
+// whose names begin with ".", such as .git. Files are checked for
+// changes to their size, modification time or mode by polling, so Watch
+// needs no operating system support.
+func Watch(paths []string, spec Spec, opts WatchOptions) (*Watcher, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("exec: Watch needs a path to watch")
+	}
+	w := &Watcher{
+		paths: paths,
+		spec:  spec,
+		opts:  opts,
+		stopc: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go w.loop(w.snapshot())
+	return w, nil
+}
+
+// Stop stops watching, and stops the running command, waiting for it to
+// exit.
+func (w *Watcher) Stop() error {
+	w.mu.Lock()
+	select {
+	case <-w.stopc:
+	default:
+		close(w.stopc)
+	}
+	w.mu.Unlock()
+	<-w.done
+	return nil
+}
+
+func (w *Watcher) loop(snap map[string]fileStamp) {
+	defer close(w.done)
+	clk := w.clock()
+	cmd, done := w.start()
+	var changed time.Time // when a change was last seen, zero if none is pending
+	for {
+		expired, stop := after(clk, w.interval())
+		select {
+		case <-w.stopc:
+			stop()
+			w.stopRun(cmd, done)
+			return
+		case err := <-done:
+			stop()
+			w.exited(err)
+			cmd, done = nil, nil
+			continue
+		case <-expired:
+		}
+		if next := w.snapshot(); !sameStamps(snap, next) {
+			snap, changed = next, clk.Now()
+		}
+		if !changed.IsZero() && clk.Now().Sub(changed) >= w.debounce() {
+			changed = time.Time{}
+			w.stopRun(cmd, done)
+			cmd, done = w.start()
+		}
+	}
+}
+
+// start starts a run of the command, returning a channel which receives
+// the result of waiting for it, or nil if it could not be started.
+func (w *Watcher) start() (*Cmd, chan error) {
+	cmd := w.spec.Command()
+	var opts []func(*Cmd) error
+	if w.opts.Clock != nil {
+		opts = append(opts, UseClock(w.opts.Clock))
+	}
+	if err := cmd.Start(opts...); err != nil {
+		w.exited(err)
+		return nil, nil
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	return cmd, done
+}
+
+// stopRun stops the run, if it is still running.
+func (w *Watcher) stopRun(cmd *Cmd, done chan error) {
+	if cmd == nil {
+		return
+	}
+	sig, grace := w.opts.StopSignal, w.opts.StopGrace
+	if sig == nil {
+		sig = os.Interrupt
+	}
+	if grace <= 0 {
+		grace = 10 * time.Second
+	}
+	_, err := cmd.stop(sig, grace, done)
+	w.exited(err)
+}
+
+func (w *Watcher) exited(err error) {
+	if w.opts.OnExit != nil {
+		w.opts.OnExit(err)
+	}
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+	mode    os.FileMode
+}
+
+// snapshot returns the stamps of the files in the watched paths.
+func (w *Watcher) snapshot() map[string]fileStamp {
+	snap := make(map[string]fileStamp)
+	for _, root := range w.paths {
+		filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return nil // the file may have been removed since it was listed
+			}
+			if fi.IsDir() && path != root && strings.HasPrefix(fi.Name(), ".") {
+				return filepath.SkipDir
+			}
+			snap[path] = fileStamp{size: fi.Size(), modTime: fi.ModTime(), mode: fi.Mode()}
+			return nil
+		})
+	}
+	return snap
+}
+
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, s := range a {
+		if t, ok := b[path]; !ok || t.size != s.size || !t.modTime.Equal(s.modTime) || t.mode != s.mode {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *Watcher) clock() Clock {
+	if w.opts.Clock == nil {
+		return systemClock{}
+	}
+	return w.opts.Clock
+}
+
+func (w *Watcher) interval() time.Duration {
+	if w.opts.Interval <= 0 {
+		return 250 * time.Millisecond
+	}
+	return w.opts.Interval
+}
+
+func (w *Watcher) debounce() time.Duration {
+	if w.opts.Debounce <= 0 {
+		return 100 * time.Millisecond
+	}
+	return w.opts.Debounce
+}
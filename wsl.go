@@ -0,0 +1,82 @@
+package exec
+
+import (
+	"path"
+	"strings"
+)
+
+// WSLDistro is a Backend which runs commands in a distribution of the
+// Windows Subsystem for Linux with wsl.exe, see WSL.
+type WSLDistro struct {
+	Name string // distribution, or "" for the default
+	User string // user to run commands as, or "" for the distribution's default
+}
+
+// WSL returns a Backend running commands in the WSL distribution
+// distro, or the default distribution if distro is empty. Arguments
+// and the working directory which are absolute Windows paths, such as
+// C:\src, are translated to the paths of the same files in WSL, such as
+// /mnt/c/src.
+//
+//	err := exec.WSL("Ubuntu").Command("make", "-C", `C:\src\app`).Run()
+func WSL(distro string) *WSLDistro {
+	return &WSLDistro{Name: distro}
+}
+
+// Command returns a Cmd to run the named program with the given
+// arguments in the distribution, see BackendCommand.
+func (d *WSLDistro) Command(name string, args ...string) *Cmd {
+	return BackendCommand(d, name, args...)
+}
+
+// Wrap returns the wsl.exe command line which runs r in the
+// distribution. The program is run directly, rather than by a shell,
+// and wsl.exe is made to write its own messages in UTF-8, rather than
+// UTF-16, so they may be read with the program's output.
+func (d *WSLDistro) Wrap(r *RemoteCommand) ([]string, error) {
+	argv := []string{"wsl.exe"}
+	if d.Name != "" {
+		argv = append(argv, "--distribution", d.Name)
+	}
+	if d.User != "" {
+		argv = append(argv, "--user", d.User)
+	}
+	if r.Dir != "" {
+		argv = append(argv, "--cd", WSLPath(r.Dir))
+	}
+	argv = append(argv, "--exec")
+	if len(r.Env) > 0 {
+		argv = append(append(argv, "env"), r.Env...)
+	}
+	for _, arg := range r.Args {
+		argv = append(argv, WSLPath(arg))
+	}
+	r.LocalEnv = append(r.LocalEnv, "WSL_UTF8=1")
+	return argv, nil
+}
+
+// WSLPath returns the path in WSL of the file at the absolute Windows
+// path p, such as /mnt/c/src for C:\src, or p unchanged if it is not
+// such a path.
+func WSLPath(p string) string {
+	if len(p) < 3 || p[1] != ':' || (p[2] != '\\' && p[2] != '/') || !isDriveLetter(p[0]) {
+		return p
+	}
+	rest := strings.Replace(p[3:], `\`, "/", -1)
+	return path.Join("/mnt", strings.ToLower(p[:1]), rest)
+}
+
+// WindowsPath returns the absolute Windows path of the file at the path
+// p in WSL, such as C:\src for /mnt/c/src, or p unchanged if it is not
+// on a Windows drive.
+func WindowsPath(p string) string {
+	if !strings.HasPrefix(p, "/mnt/") || len(p) < 6 || !isDriveLetter(p[5]) || (len(p) > 6 && p[6] != '/') {
+		return p
+	}
+	rest := strings.Replace(strings.TrimPrefix(p[6:], "/"), "/", `\`, -1)
+	return strings.ToUpper(p[5:6]) + `:\` + rest
+}
+
+func isDriveLetter(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}